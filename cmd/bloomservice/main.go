@@ -15,21 +15,88 @@
 package main
 
 import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/api/v1/handlers"
 	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+	"github.com/vinit-chauhan/go-bloomservice/internal/config"
+	"github.com/vinit-chauhan/go-bloomservice/internal/registry"
 	"github.com/vinit-chauhan/go-bloomservice/internal/server"
 )
 
 func main() {
-	estimatedKeys, falsePositiveRate := 100_000, 0.01
-	bloom.Init(estimatedKeys, falsePositiveRate)
+	cfg, err := config.Load()
+	if err != nil {
+		panic("Failed to load configuration: " + err.Error())
+	}
+
+	params := bloom.CalculateOptimalParameters(cfg.Capacity, cfg.FalsePositiveRate)
+	params.Seed = cfg.Seed
+	params.HashAlgorithm = cfg.HashAlgorithm
+	params.Type = cfg.FilterType
+	params.CounterWidth = uint8(cfg.CounterWidth)
+	params.Generations = uint8(cfg.Generations)
+	params.RotationInterval = cfg.RotationInterval
+	params.RotationItems = cfg.RotationItems
+	params.Shards = uint16(cfg.Shards)
+	handlers.Filters = registry.New(bloom.WithKey(cfg.SecretKey))
+	handlers.Filters.SetMemoryLimit(cfg.MemoryLimit)
+	handlers.Filters.SetMaxImport(cfg.MaxImportSize)
+	handlers.Filters.SetDefaultParameters(params)
+	handlers.Filters.SetTenantLimits(registry.TenantLimits{
+		MaxFilters:   cfg.TenantMaxFilters,
+		MaxBits:      cfg.TenantMaxBits,
+		OpsPerSecond: cfg.TenantOpsPerSecond,
+	})
+	tenant, err := handlers.Filters.Tenant(registry.DefaultTenant)
+	if err != nil {
+		panic("Failed to create tenant: " + err.Error())
+	}
+	if _, err := tenant.Create(registry.DefaultName, params); err != nil {
+		panic("Failed to create filter: " + err.Error())
+	}
+	ids := cfg.Tenants
+	for _, id := range cfg.APIKeys {
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		if _, err := handlers.Filters.Tenant(id); err != nil {
+			panic("Failed to create tenant: " + err.Error())
+		}
+	}
+
+	if cfg.HibernateAfter > 0 {
+		if err := handlers.Filters.SetHibernation(cfg.HibernateDir); err != nil {
+			panic("Failed to set up hibernation: " + err.Error())
+		}
+		go hibernate(cfg.HibernateAfter)
+	}
+
+	handlers.MaxBatchSize = cfg.MaxBatchSize
+	handlers.TenantHeader = cfg.TenantHeader
+	handlers.SetTenants(cfg.Tenants)
+	handlers.SetAPIKeys(cfg.APIKeys)
+	handlers.MaxBodySize = cfg.BodyLimit
+	server.BodyLimit = cfg.BodyLimit
 
 	app := server.StartServer()
-	app.Listen(":8080")
+	app.Listen(":" + strconv.Itoa(cfg.Port))
 
 	defer func() {
 		if err := app.Shutdown(); err != nil {
 			panic("Failed to shutdown server: " + err.Error())
 		}
-		bloom.Filter.Clear()
 	}()
 }
+
+// hibernate Hibernates the filters unused for longer than idle, checking
+// often enough that none stays in memory much longer
+func hibernate(idle time.Duration) {
+	for range time.Tick(min(idle/4, time.Minute)) {
+		if _, err := handlers.Filters.Hibernate(time.Now().Add(-idle)); err != nil {
+			log.Printf("Failed to hibernate filters: %v", err)
+		}
+	}
+}
@@ -3,14 +3,16 @@ module github.com/vinit-chauhan/go-bloomservice
 go 1.22.11
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0
+	github.com/dchest/siphash v1.2.3
 	github.com/gofiber/fiber/v2 v2.52.8
+	github.com/klauspost/compress v1.17.9
 	github.com/spaolacci/murmur3 v1.1.0
 )
 
 require (
 	github.com/andybalholm/brotli v1.1.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+func ListAliasesHandler(c *fiber.Ctx) error {
+	// This handler will list every alias of the tenant.
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"aliases": tenantOf(c).Aliases(),
+	})
+}
+
+func GetAliasHandler(c *fiber.Ctx) error {
+	// This handler will report the filter an alias points at.
+	alias := c.Params("alias")
+	filter, err := tenantOf(c).Alias(alias)
+	if err != nil {
+		return registryError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"alias":  alias,
+		"filter": filter,
+	})
+}
+
+func SetAliasHandler(c *fiber.Ctx) error {
+	// This handler will point an alias at a filter, swapping it atomically
+	// if it already exists.
+	var request struct {
+		Filter string `json:"filter" validate:"required"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if request.Filter == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Filter is required",
+		})
+	}
+
+	alias := c.Params("alias")
+	previous, err := tenantOf(c).SetAlias(alias, request.Filter)
+	if err != nil {
+		return registryError(c, err)
+	}
+
+	status := fiber.StatusOK
+	if previous == "" {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"alias":    alias,
+		"filter":   request.Filter,
+		"previous": previous,
+	})
+}
+
+func DeleteAliasHandler(c *fiber.Ctx) error {
+	// This handler will delete an alias, keeping the filter it points at.
+	alias := c.Params("alias")
+	if err := tenantOf(c).DeleteAlias(alias); err != nil {
+		return registryError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Alias deleted successfully",
+		"alias":   alias,
+	})
+}
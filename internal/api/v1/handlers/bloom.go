@@ -1,14 +1,27 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+	"github.com/vinit-chauhan/go-bloomservice/internal/registry"
 )
 
+// MaxBatchSize is the largest number of items accepted by a batch request
+var MaxBatchSize = 1000
+
 func AddHandler(c *fiber.Ctx) error {
 	// This handler will handle the addition of items to the bloom filter.
 	var request struct {
-		Item string `json:"item" validate:"required"`
+		Item     string `json:"item" validate:"required"`
+		Encoding string `json:"encoding"`
+		IfAbsent bool   `json:"if_absent"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
@@ -23,8 +36,35 @@ func AddHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Add the item to the bloom filter
-	bloom.Filter.Add(request.Item)
+	key, err := decodeItem(request.Item, request.Encoding)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	_, filter, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	if ok, err := throttle(c, 1); !ok {
+		return err
+	}
+	if request.IfAbsent {
+		return addIfAbsent(c, filter, key, request.Item)
+	}
+
+	// Add the item to the bloom filter, reporting filters that ran out of room
+	if inserter, ok := filter.(bloom.Inserter); ok {
+		if err := inserter.Insert(key); err != nil {
+			return c.Status(fiber.StatusInsufficientStorage).JSON(fiber.Map{
+				"error": err.Error(),
+				"item":  request.Item,
+			})
+		}
+	} else {
+		filter.Add(key)
+	}
 
 	// Return a success response
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -33,10 +73,191 @@ func AddHandler(c *fiber.Ctx) error {
 	})
 }
 
+// addIfAbsent Tests for the decoded key of an item and adds it in one step,
+// telling the caller whether it was already present
+func addIfAbsent(c *fiber.Ctx, filter bloom.ProbabilisticFilter, key, item string) error {
+	adder, ok := filter.(bloom.ConditionalAdder)
+	if !ok {
+		return c.Status(fiber.StatusMethodNotAllowed).JSON(fiber.Map{
+			"error": "Filter does not support if_absent",
+			"type":  filter.GetParameters().Type,
+		})
+	}
+
+	if adder.AddIfAbsent(key) {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message":         "Item already present in bloom filter",
+			"item":            item,
+			"already_present": true,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":         "Item added to bloom filter successfully",
+		"item":            item,
+		"already_present": false,
+	})
+}
+
+func AddBatchHandler(c *fiber.Ctx) error {
+	// This handler will handle the addition of a batch of items to the bloom filter.
+	items, ok, err := parseBatch(c)
+	if !ok {
+		return err
+	}
+
+	_, filter, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	if ok, err := throttle(c, len(items)); !ok {
+		return err
+	}
+
+	// Add the items under a single lock where the filter supports it, and
+	// report items that did not fit into the filter where inserts can fail
+	added := make([]bool, len(items))
+	status := fiber.StatusCreated
+	if inserter, ok := filter.(bloom.BatchInserter); ok {
+		n, err := inserter.InsertMany(items)
+		if err != nil {
+			status = fiber.StatusInsufficientStorage
+		}
+		for i := range n {
+			added[i] = true
+		}
+	} else if inserter, ok := filter.(bloom.Inserter); ok {
+		for i, item := range items {
+			if inserter.Insert(item) != nil {
+				status = fiber.StatusInsufficientStorage
+				continue
+			}
+			added[i] = true
+		}
+	} else if batcher, ok := filter.(bloom.Batcher); ok {
+		batcher.AddMany(items)
+		for i := range added {
+			added[i] = true
+		}
+	} else {
+		for i, item := range items {
+			filter.Add(item)
+			added[i] = true
+		}
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"count": len(items),
+		"added": added,
+	})
+}
+
+func CheckBatchHandler(c *fiber.Ctx) error {
+	// This handler will handle checking a batch of items in the bloom filter.
+	items, ok, err := parseBatch(c)
+	if !ok {
+		return err
+	}
+
+	_, filter, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	if ok, err := throttle(c, len(items)); !ok {
+		return err
+	}
+	var exists []bool
+	if batcher, ok := filter.(bloom.Batcher); ok {
+		exists = batcher.ExistsMany(items)
+	} else {
+		exists = make([]bool, len(items))
+		for i, item := range items {
+			exists[i] = filter.Exists(item)
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"count":  len(items),
+		"exists": exists,
+	})
+}
+
+// parseBatch Parses and decodes the items of a batch request. If the batch is
+// invalid it writes the error response and returns false.
+func parseBatch(c *fiber.Ctx) ([]string, bool, error) {
+	var request struct {
+		Items    []string `json:"items" validate:"required"`
+		Encoding string   `json:"encoding"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(request.Items) == 0 {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Items are required",
+		})
+	}
+
+	if len(request.Items) > MaxBatchSize {
+		return nil, false, c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "Batch holds more than " + strconv.Itoa(MaxBatchSize) + " items",
+		})
+	}
+
+	keys := make([]string, len(request.Items))
+	for i, item := range request.Items {
+		if item == "" {
+			return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Item " + strconv.Itoa(i) + " is empty",
+			})
+		}
+
+		key, err := decodeItem(item, request.Encoding)
+		if err != nil {
+			return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Item " + strconv.Itoa(i) + ": " + err.Error(),
+			})
+		}
+		keys[i] = key
+	}
+
+	return keys, true, nil
+}
+
+// decodeItem Decodes an item sent in the given encoding into the raw bytes
+// it stands for, so binary keys map to the same bits however they are sent
+func decodeItem(item, encoding string) (string, error) {
+	var (
+		raw []byte
+		err error
+	)
+
+	switch encoding {
+	case "", "utf8":
+		return item, nil
+	case "base64":
+		raw, err = base64.StdEncoding.DecodeString(item)
+	case "hex":
+		raw, err = hex.DecodeString(item)
+	default:
+		return "", fmt.Errorf("unknown encoding %q, must be utf8, base64 or hex", encoding)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("invalid %s item: %w", encoding, err)
+	}
+	return string(raw), nil
+}
+
 func CheckHandler(c *fiber.Ctx) error {
 	// This handler will handle the addition of items to the bloom filter.
 	var request struct {
-		Item string `json:"item" validate:"required"`
+		Item     string `json:"item" validate:"required"`
+		Encoding string `json:"encoding"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
@@ -51,8 +272,23 @@ func CheckHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	key, err := decodeItem(request.Item, request.Encoding)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	_, filter, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	if ok, err := throttle(c, 1); !ok {
+		return err
+	}
+
 	// Check if the item exists in the bloom filter
-	exists := bloom.Filter.Exists(request.Item)
+	exists := filter.Exists(key)
 	if exists {
 		return c.Status(fiber.StatusOK).JSON(fiber.Map{
 			"exists": true,
@@ -68,10 +304,75 @@ func CheckHandler(c *fiber.Ctx) error {
 
 }
 
+func RemoveHandler(c *fiber.Ctx) error {
+	// This handler will handle the removal of items from the bloom filter.
+	var request struct {
+		Item     string `json:"item" validate:"required"`
+		Encoding string `json:"encoding"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if request.Item == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Item is required",
+		})
+	}
+
+	key, err := decodeItem(request.Item, request.Encoding)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	_, filter, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	if ok, err := throttle(c, 1); !ok {
+		return err
+	}
+
+	// Only counting filters can forget items
+	remover, ok := filter.(bloom.Remover)
+	if !ok {
+		return c.Status(fiber.StatusMethodNotAllowed).JSON(fiber.Map{
+			"error": "Filter does not support removing items",
+			"type":  filter.GetParameters().Type,
+		})
+	}
+
+	if err := remover.Remove(key); err != nil {
+		if errors.Is(err, bloom.ErrNotPresent) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"removed": false,
+				"item":    request.Item,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"removed": true,
+		"item":    request.Item,
+	})
+}
+
 func StatsHandler(c *fiber.Ctx) error {
-	// This handler will handle the addition of items to the bloom filter.
-	params := bloom.Filter.GetParameters()
-	stats := bloom.Filter.GetStatistics()
+	// This handler will return the parameters and statistics of the bloom filter.
+	_, filter, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	params := filter.GetParameters()
+	stats := filter.GetStatistics()
 
 	return c.
 		Status(fiber.StatusOK).
@@ -79,10 +380,241 @@ func StatsHandler(c *fiber.Ctx) error {
 }
 
 func ResetHandler(c *fiber.Ctx) error {
-	// This handler will handle the addition of items to the bloom filter.
-	bloom.Filter.Clear()
+	// This handler will reset the bloom filter to its initial, empty state.
+	_, filter, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	filter.Clear()
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Bloom filter reset successfully",
 	})
 }
+
+func CreateFilterHandler(c *fiber.Ctx) error {
+	// This handler will replace the bloom filter with a new, empty filter.
+	entry, _, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+
+	var request filterRequest
+	params, ok, err := parseFilterRequest(c, &request)
+	if !ok {
+		return err
+	}
+
+	filter, err := entry.Recreate(params)
+	if err != nil {
+		return registryError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Bloom filter created successfully",
+		"params":  filter.GetParameters(),
+	})
+}
+
+// filterRequest is the body of a request creating a filter
+type filterRequest struct {
+	bloom.Parameters
+	Name              string  `json:"name"`
+	Capacity          int     `json:"capacity"`
+	FalsePositiveRate float64 `json:"false_positive_rate"`
+}
+
+// parseFilterRequest Parses the body of a request creating a filter into
+// request and returns the parameters of the filter, sized for the requested
+// capacity and false positive rate. If the body is invalid it writes the
+// error response and returns false.
+func parseFilterRequest(c *fiber.Ctx, request *filterRequest) (bloom.Parameters, bool, error) {
+	if err := c.BodyParser(request); err != nil {
+		return bloom.Parameters{}, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if request.Capacity <= 0 {
+		return bloom.Parameters{}, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Capacity must be greater than 0",
+		})
+	}
+
+	if request.FalsePositiveRate <= 0 || request.FalsePositiveRate >= 1 {
+		return bloom.Parameters{}, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "False positive rate must be between 0 and 1",
+		})
+	}
+
+	// Size the filter for the capacity, keeping the requested tuning
+	sized := bloom.CalculateOptimalParameters(request.Capacity, request.FalsePositiveRate)
+	params := request.Parameters
+	params.Size = sized.Size
+	params.NumHashFunctions = sized.NumHashFunctions
+	params.Capacity = sized.Capacity
+	params.FalsePositiveRate = sized.FalsePositiveRate
+	return params, true, nil
+}
+
+func MergeHandler(c *fiber.Ctx) error {
+	// This handler will merge an uploaded filter into the bloom filter.
+	entry, _, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	// a merge counts as one operation whatever the size of the filter
+	if ok, err := throttle(c, 1); !ok {
+		return err
+	}
+	if isOctetStream(c) {
+		return mergeSerialized(c, entry)
+	}
+
+	var request struct {
+		Operation string           `json:"operation"`
+		Params    bloom.Parameters `json:"params"`
+		Bits      []byte           `json:"bits"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if request.Operation == "" {
+		request.Operation = "union"
+	}
+	if request.Operation != "union" && request.Operation != "intersect" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Operation must be union or intersect",
+		})
+	}
+
+	filter, err := entry.Merge(request.Params, request.Bits, request.Operation == "intersect")
+	if err != nil {
+		if errors.Is(err, bloom.ErrIncompatible) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Bloom filter merged successfully",
+		"operation": request.Operation,
+		"stats":     filter.GetStatistics(),
+	})
+}
+
+// mergeSerialized Merges a filter uploaded in the binary serialization
+// format, the operation is taken from the query string
+func mergeSerialized(c *fiber.Ctx, entry *registry.Entry) error {
+	operation := c.Query("operation", "union")
+	if operation != "union" && operation != "intersect" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Operation must be union or intersect",
+		})
+	}
+
+	body, err := decompressBody(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer body.Close()
+
+	filter, err := entry.MergeFrom(body, operation == "intersect")
+	if err != nil {
+		return transferError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Bloom filter merged successfully",
+		"operation": operation,
+		"stats":     filter.GetStatistics(),
+	})
+}
+
+// rekeyKeySize is the size in bytes of the secret key RekeyHandler generates
+// when the request names none
+const rekeyKeySize = 32
+
+func RekeyHandler(c *fiber.Ctx) error {
+	// This handler will rotate the secret key of the bloom filter, rebuilding
+	// it from the items sent in the body.
+	var request struct {
+		Key      string   `json:"key"`
+		Items    []string `json:"items"`
+		Encoding string   `json:"encoding"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	// A key is generated unless the caller shares one with other instances
+	key := make([]byte, rekeyKeySize)
+	if request.Key != "" {
+		decoded, err := hex.DecodeString(request.Key)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Key must be hex encoded",
+			})
+		}
+		key = decoded
+	} else if _, err := rand.Read(key); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	items := make([]string, len(request.Items))
+	for i, item := range request.Items {
+		if item == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Item " + strconv.Itoa(i) + " is empty",
+			})
+		}
+
+		decoded, err := decodeItem(item, request.Encoding)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Item " + strconv.Itoa(i) + ": " + err.Error(),
+			})
+		}
+		items[i] = decoded
+	}
+
+	entry, _, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	if ok, err := throttle(c, len(items)); !ok {
+		return err
+	}
+
+	// Items added while the filter is rebuilt are carried over, the body
+	// must hold every item added before
+	err = entry.Rekey(key, func(add func(item string)) error {
+		for _, item := range items {
+			add(item)
+		}
+		return nil
+	})
+	if err != nil {
+		return registryError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Bloom filter rekeyed successfully",
+		"count":   len(items),
+	})
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+	"github.com/vinit-chauhan/go-bloomservice/internal/registry"
+)
+
+// Filters holds the named filters of every tenant; the unnamed routes use the
+// default filter of the tenant
+var Filters = registry.New()
+
+func ListFiltersHandler(c *fiber.Ctx) error {
+	// This handler will list every filter of the tenant.
+	entries := tenantOf(c).List()
+	filters := make([]fiber.Map, len(entries))
+	for i, entry := range entries {
+		filters[i] = filterInfo(entry)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"filters": filters,
+	})
+}
+
+func CreateNamedFilterHandler(c *fiber.Ctx) error {
+	// This handler will create a new, empty filter under the name given in the body.
+	var request filterRequest
+	params, ok, err := parseFilterRequest(c, &request)
+	if !ok {
+		return err
+	}
+
+	entry, err := tenantOf(c).Create(request.Name, params)
+	if err != nil {
+		return registryError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Bloom filter created successfully",
+		"name":    entry.Name(),
+		"params":  entry.Info().Params,
+	})
+}
+
+func GetFilterHandler(c *fiber.Ctx) error {
+	// This handler will describe a single filter of the tenant, without
+	// reloading it if it hibernates.
+	entry, err := tenantOf(c).Get(c.Params("name"))
+	if err != nil {
+		return registryError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(filterInfo(entry))
+}
+
+func DeleteFilterHandler(c *fiber.Ctx) error {
+	// This handler will delete a filter of the tenant.
+	name := c.Params("name")
+	if err := tenantOf(c).Delete(name); err != nil {
+		return registryError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Bloom filter deleted successfully",
+		"name":    name,
+	})
+}
+
+func MemoryHandler(c *fiber.Ctx) error {
+	// This handler will report the memory held by the filters of the tenant
+	// of the request, and the memory booked by every filter against the limit.
+	return c.Status(fiber.StatusOK).JSON(Filters.Memory(tenantOf(c).ID()))
+}
+
+// lookup Resolves the filter of the tenant a request is for, the default
+// filter on the unnamed routes, which is created on first use, following
+// aliases, and reloads it if it hibernates. If there is no such filter it writes the error response and
+// returns false.
+func lookup(c *fiber.Ctx) (*registry.Entry, bloom.ProbabilisticFilter, bool, error) {
+	tenant := tenantOf(c)
+	var entry *registry.Entry
+	var err error
+	if name := c.Params("name"); name != "" {
+		entry, err = tenant.Get(name)
+	} else {
+		entry, err = tenant.Default()
+	}
+	if err != nil {
+		return nil, nil, false, registryError(c, err)
+	}
+	filter, err := entry.Load()
+	if err != nil {
+		return nil, nil, false, registryError(c, err)
+	}
+	return entry, filter, true, nil
+}
+
+// filterInfo Returns the description of a filter in the registry, without
+// reloading it if it hibernates
+func filterInfo(entry *registry.Entry) fiber.Map {
+	info := entry.Info()
+	return fiber.Map{
+		"name":        entry.Name(),
+		"created_at":  entry.Created(),
+		"params":      info.Params,
+		"stats":       info.Stats,
+		"size_bytes":  info.SizeInBytes,
+		"hibernated":  info.Hibernated,
+		"last_access": info.LastAccess,
+	}
+}
+
+// registryError Writes the response for a registry operation that failed
+func registryError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, registry.ErrNotFound), errors.Is(err, registry.ErrAliasNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, registry.ErrExists), errors.Is(err, registry.ErrAliased):
+		status = fiber.StatusConflict
+	case errors.Is(err, registry.ErrQuota):
+		status = fiber.StatusForbidden
+	case errors.Is(err, registry.ErrTooLarge):
+		status = fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, registry.ErrMemoryLimit):
+		status = fiber.StatusInsufficientStorage
+	case errors.Is(err, registry.ErrSnapshot):
+		status = fiber.StatusInternalServerError
+	case errors.Is(err, registry.ErrUnsupported):
+		status = fiber.StatusMethodNotAllowed
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vinit-chauhan/go-bloomservice/internal/registry"
+)
+
+// TenantHeader is the header naming the tenant of a request when no API keys
+// are set; requests without it belong to the default tenant
+var TenantHeader = "X-Tenant"
+
+// tenants holds the tenants TenantHeader may name besides the default one,
+// so a client cannot create tenants without bound
+var tenants = map[string]bool{}
+
+// apiKeys maps the digest of each API key to its tenant, nil if the tenant is
+// taken from TenantHeader. Keys are looked up by digest so the lookup does not
+// leak how much of a guessed key is right.
+var apiKeys map[[sha256.Size]byte]string
+
+// SetAPIKeys Sets the API keys requests must send in the X-API-Key header and
+// the tenant each of them belongs to. With no keys the tenant is taken from
+// TenantHeader instead.
+// parameters:
+//
+//	keys	: tenant of each API key, nil to take the tenant from TenantHeader
+//
+// returns:
+//
+//	none
+func SetAPIKeys(keys map[string]string) {
+	if len(keys) == 0 {
+		apiKeys = nil
+		return
+	}
+
+	apiKeys = make(map[[sha256.Size]byte]string, len(keys))
+	for key, tenant := range keys {
+		apiKeys[sha256.Sum256([]byte(key))] = tenant
+	}
+}
+
+// SetTenants Sets the tenants TenantHeader may name besides the default
+// tenant, requests naming any other are refused
+// parameters:
+//
+//	ids	: tenants that may be named
+//
+// returns:
+//
+//	none
+func SetTenants(ids []string) {
+	tenants = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		tenants[id] = true
+	}
+}
+
+func TenantMiddleware(c *fiber.Ctx) error {
+	// This middleware will resolve the tenant of the request, from its API
+	// key if keys are set or else from the tenant header.
+	id := registry.DefaultTenant
+	if apiKeys != nil {
+		key := c.Get("X-API-Key")
+		tenant, ok := apiKeys[sha256.Sum256([]byte(key))]
+		if key == "" || !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing or unknown API key",
+			})
+		}
+		id = tenant
+	} else if header := c.Get(TenantHeader); header != "" && header != id {
+		if !tenants[header] {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Unknown tenant " + header,
+			})
+		}
+		id = header
+	}
+
+	tenant, err := Filters.Tenant(id)
+	if err != nil {
+		return registryError(c, err)
+	}
+	c.Locals("tenant", tenant)
+	return c.Next()
+}
+
+func TenantHandler(c *fiber.Ctx) error {
+	// This handler will report the filters, memory, operations and limits of
+	// the tenant of the request.
+	return c.Status(fiber.StatusOK).JSON(tenantOf(c).Statistics())
+}
+
+// tenantOf Returns the tenant of a request, the default tenant if the request
+// did not pass TenantMiddleware
+func tenantOf(c *fiber.Ctx) *registry.Tenant {
+	if tenant, ok := c.Locals("tenant").(*registry.Tenant); ok {
+		return tenant
+	}
+	tenant, _ := Filters.Tenant(registry.DefaultTenant)
+	return tenant
+}
+
+// throttle Takes ops item operations from the rate limit of the tenant of a
+// request. If the tenant is over its rate it writes the error response and
+// returns false.
+func throttle(c *fiber.Ctx, ops int) (bool, error) {
+	ok, retry := tenantOf(c).Allow(ops)
+	if ok {
+		return true, nil
+	}
+
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	return false, c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error": "Tenant exceeded its rate limit",
+	})
+}
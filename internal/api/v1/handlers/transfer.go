@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+// MaxBodySize is the largest decompressed upload accepted, in bytes, so a
+// small compressed body cannot expand without bound
+var MaxBodySize = 64 << 20
+
+// errBodyTooLarge is returned reading an upload beyond MaxBodySize
+var errBodyTooLarge = errors.New("decompressed body exceeds the body limit")
+
+func ExportHandler(c *fiber.Ctx) error {
+	// This handler will stream the bloom filter in the binary serialization format.
+	_, filter, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	writer, ok := filter.(io.WriterTo)
+	if !ok {
+		return c.Status(fiber.StatusMethodNotAllowed).JSON(fiber.Map{
+			"error": "Filter does not support export",
+			"type":  filter.GetParameters().Type,
+		})
+	}
+
+	compression := c.Query("compression")
+	switch compression {
+	case "", "identity", "gzip", "zstd":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Compression must be gzip or zstd",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="filter.bloom"`)
+	if compression != "" && compression != "identity" {
+		c.Set(fiber.HeaderContentEncoding, compression)
+	}
+
+	// Stream the filter instead of buffering it, a failure midway truncates
+	// the body and is caught by the checksum of the reader
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		out, err := compressWriter(w, compression)
+		if err != nil {
+			return
+		}
+		if _, err := writer.WriteTo(out); err != nil {
+			return
+		}
+		if err := out.Close(); err != nil {
+			return
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+func ImportHandler(c *fiber.Ctx) error {
+	// This handler will replace the bloom filter with an uploaded, serialized filter.
+	entry, _, ok, err := lookup(c)
+	if !ok {
+		return err
+	}
+	// an import counts as one operation whatever the size of the filter
+	if ok, err := throttle(c, 1); !ok {
+		return err
+	}
+
+	body, err := decompressBody(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer body.Close()
+
+	filter, err := entry.Import(body)
+	if err != nil {
+		return transferError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Bloom filter imported successfully",
+		"params":  filter.GetParameters(),
+		"stats":   filter.GetStatistics(),
+	})
+}
+
+// transferError Writes the response for a filter that could not be imported
+// or merged
+func transferError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errBodyTooLarge) || errors.Is(err, zstd.ErrWindowSizeExceeded) || errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, bloom.ErrKeyMismatch) || errors.Is(err, bloom.ErrIncompatible) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return registryError(c, err)
+}
+
+// isOctetStream Reports whether the request body is a serialized filter
+// rather than JSON
+func isOctetStream(c *fiber.Ctx) bool {
+	return strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEOctetStream)
+}
+
+// compressWriter Wraps w in the compression of an export
+func compressWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "zstd":
+		return zstd.NewWriter(w)
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+// decompressBody Returns a reader of the request body, decoding its
+// Content-Encoding. A decoded body beyond MaxBodySize fails with
+// errBodyTooLarge
+func decompressBody(c *fiber.Ctx) (io.ReadCloser, error) {
+	body := bytes.NewReader(c.Request().Body())
+
+	switch encoding := c.Get(fiber.HeaderContentEncoding); encoding {
+	case "", "identity":
+		return io.NopCloser(body), nil
+	case "gzip":
+		r, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, errors.New("invalid gzip body: " + err.Error())
+		}
+		return &limitedBody{ReadCloser: r, left: int64(MaxBodySize)}, nil
+	case "zstd":
+		r, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(MaxBodySize)))
+		if err != nil {
+			return nil, errors.New("invalid zstd body: " + err.Error())
+		}
+		return &limitedBody{ReadCloser: r.IOReadCloser(), left: int64(MaxBodySize)}, nil
+	default:
+		return nil, errors.New("unsupported content encoding " + encoding + ", must be gzip or zstd")
+	}
+}
+
+// limitedBody is a decompressed upload failing with errBodyTooLarge once
+// more than left bytes are read
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.left < 0 {
+		return 0, errBodyTooLarge
+	}
+	// read one byte more than left to tell an exact fit from an overflow
+	if int64(len(p)) > l.left+1 {
+		p = p[:l.left+1]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return n - 1, errBodyTooLarge
+	}
+	return n, err
+}
+
+// nopWriteCloser is an uncompressed export
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -20,30 +20,231 @@ import (
 )
 
 func BloomRouter(router fiber.Router) {
+	// Every route below acts on the filters of the tenant of the request.
+	// With API_KEYS set the tenant is the one of the key sent in X-API-Key,
+	// requests without a known key are refused with 401. Otherwise it is
+	// named by the TENANT_HEADER header, "default" if the header is unset;
+	// a tenant not listed in TENANTS is refused with 403.
+	// Item operations past TENANT_OPS_PER_SECOND are refused with 429 and
+	// a Retry-After header; an import or merge counts as one operation.
+	router.Use(handlers.TenantMiddleware)
+
 	// Add item to the Bloom filter
 	// Body:
 	// {
-	//   "item": "string" // item to add to the Bloom filter
+	//   "item": "string", // item to add to the Bloom filter
+	//   "encoding": "utf8", // optional, utf8 (default), base64 or hex for binary items
+	//   "if_absent": true // optional, test and add in one step; the response
+	//                     // reports "already_present" and is 200 if it was
 	// }
 	router.Post("/add", handlers.AddHandler)
 
 	// Check if an item is in the Bloom filter
 	// Body:
 	// {
-	//   "item": "string" // item to check in the Bloom filter
+	//   "item": "string", // item to check in the Bloom filter
+	//   "encoding": "utf8" // optional, utf8 (default), base64 or hex for binary items
 	// }
 	router.Post("/exists", handlers.CheckHandler)
 
+	// Add a batch of items to the Bloom filter
+	// Returns "added", whether each item was added in request order; 507 if
+	// any item did not fit into the filter.
+	// Body:
+	// {
+	//   "items": ["string", ...], // at most MAX_BATCH_SIZE items to add
+	//   "encoding": "utf8" // optional, encoding of every item as for /add
+	// }
+	router.Post("/add/batch", handlers.AddBatchHandler)
+
+	// Check a batch of items in the Bloom filter
+	// Returns "exists", whether each item is in the filter in request order.
+	// Body:
+	// {
+	//   "items": ["string", ...], // at most MAX_BATCH_SIZE items to check
+	//   "encoding": "utf8" // optional, encoding of every item as for /add
+	// }
+	router.Post("/exists/batch", handlers.CheckBatchHandler)
+
+	// Remove an item from the Bloom filter
+	// Only supported when the filter was created as a counting filter.
+	// Body:
+	// {
+	//   "item": "string", // item to remove from the Bloom filter
+	//   "encoding": "utf8" // optional, utf8 (default), base64 or hex for binary items
+	// }
+	router.Delete("/items", handlers.RemoveHandler)
+
 	// Get statistics about the Bloom filter
 	// Returns:
 	// {
 	//   "size": 123456, // size of the Bloom filter in bits
 	//   "num_hash_functions": 5, // number of hash functions used
 	//   "num_items": 1000, // number of items added to the Bloom filter
+	//   "seed": 42, // master seed the hash functions are derived from
+	//   "hash_algorithm": "murmur3_128", // hash family the bit indices are derived from
+	//   "set_bits": 4711, // number of bits set in the Bloom filter
+	//   "estimated_items": 980.5, // estimated number of distinct items, without duplicates
+	//   "estimated_false_positive_rate": 0.0009, // estimated current false positive rate
+	//   "layers": [...], // size, capacity and fill ratio of each layer of a scalable filter
+	//   "generations": [...], // start, end and age of each generation of a rotating filter
 	// }
 	router.Get("/stats", handlers.StatsHandler)
 
+	// Replace the Bloom filter with a new, empty filter
+	// Body:
+	// {
+	//   "capacity": 100000, // expected number of items
+	//   "false_positive_rate": 0.01, // desired false positive rate
+	//   "type": "cuckoo", // standard, counting, scalable, rotating, cuckoo, blocked or sharded
+	//   ... // any other parameter returned by /stats, e.g. "seed"
+	// }
+	router.Put("/filter", handlers.CreateFilterHandler)
+
+	// Merge a filter built elsewhere into the Bloom filter
+	// Only supported for standard filters with the same size, hash functions,
+	// hash algorithm, seed and secret key; others are refused with 409.
+	// Body:
+	// {
+	//   "operation": "union", // union (default) or intersect
+	//   "params": {...}, // parameters of the uploaded filter, as returned by /stats
+	//   "bits": "base64" // packed bits, little-endian 64-bit words
+	// }
+	// Alternatively the body is a filter as returned by /export, sent as
+	// application/octet-stream with an optional gzip or zstd Content-Encoding
+	// and the operation in the query string, e.g. /merge?operation=intersect.
+	router.Post("/merge", handlers.MergeHandler)
+
+	// Export the Bloom filter in the binary serialization format
+	// Only supported for standard filters. Returns application/octet-stream,
+	// compressed with ?compression=gzip or ?compression=zstd.
+	router.Get("/export", handlers.ExportHandler)
+
+	// Replace the Bloom filter with an exported one
+	// The body is a filter as returned by /export, sent as
+	// application/octet-stream with an optional gzip or zstd Content-Encoding.
+	// The live filter is only replaced once the upload is fully validated.
+	// 413 if the body exceeds BODY_LIMIT or the filter MAX_IMPORT_SIZE.
+	router.Put("/import", handlers.ImportHandler)
+
+	// Rotate the secret key of the Bloom filter, rebuilding it from the items
+	// sent, which must be every item added so far; items added during the
+	// rebuild are carried over. Exports, imports, merges and snapshots of the
+	// filter use the new key from then on. Only supported for standard
+	// filters, others are refused with 405.
+	// Body:
+	// {
+	//   "key": "hex", // optional, new secret key, a random key if unset; never returned
+	//   "items": ["string", ...], // every item of the filter
+	//   "encoding": "utf8" // optional, encoding of every item as for /add
+	// }
+	router.Post("/rekey", handlers.RekeyHandler)
+
 	// Reset the Bloom filter
 	// This endpoint clears the Bloom filter, resetting it to its initial state.
 	router.Delete("/reset", handlers.ResetHandler)
+
+	// The routes above act on the filter named "default" of the tenant, which
+	// is created on first use, counted against the tenant's quota; the ones
+	// below manage named filters, e.g. one per team of a shared deployment.
+
+	// List every filter
+	// Returns "filters", the name, creation time, parameters, statistics,
+	// hibernation state and last access of every filter ordered by name.
+	// Hibernated filters are described without reloading them.
+	router.Get("/filters", handlers.ListFiltersHandler)
+
+	// Create a new, empty filter
+	// Returns 409 if the name is taken, 403 if the tenant would exceed
+	// TENANT_MAX_FILTERS or TENANT_MAX_BITS, 413 if the filter is larger than
+	// MEMORY_LIMIT and 507 if it does not fit next to the other filters; PUT
+	// /filter and /import are refused the same way.
+	// Body:
+	// {
+	//   "name": "team-a", // letters, digits, '_', '.' and '-', at most 64 characters
+	//   "capacity": 100000, // expected number of items
+	//   "false_positive_rate": 0.01, // desired false positive rate
+	//   "type": "standard", // any type accepted by PUT /filter
+	//   ... // any other parameter returned by /stats, e.g. "seed"
+	// }
+	router.Post("/filters", handlers.CreateNamedFilterHandler)
+
+	// Get the name, creation time, parameters, statistics, size in bytes,
+	// hibernation state and last access of a filter
+	// With HIBERNATE_AFTER set, standard filters unused for that long are
+	// written to HIBERNATE_DIR and reloaded by the next request using them;
+	// "hibernated" tells whether the filter is on disk, "last_access" when it
+	// was last used. Filters of other types stay in memory and are logged the
+	// first time they are idle.
+	router.Get("/filters/:name", handlers.GetFilterHandler)
+
+	// Replace a filter with a new, empty filter, with the body of PUT /filter
+	router.Put("/filters/:name", handlers.CreateFilterHandler)
+
+	// Delete a filter
+	// The default filter of the default tenant cannot be deleted, neither can
+	// a filter an alias points at (409).
+	router.Delete("/filters/:name", handlers.DeleteFilterHandler)
+
+	// Use a filter, with the bodies and responses of the routes above
+	// The name may be an alias, which is resolved to its filter.
+	router.Post("/filters/:name/add", handlers.AddHandler)
+	router.Post("/filters/:name/exists", handlers.CheckHandler)
+	router.Post("/filters/:name/add/batch", handlers.AddBatchHandler)
+	router.Post("/filters/:name/exists/batch", handlers.CheckBatchHandler)
+	router.Delete("/filters/:name/items", handlers.RemoveHandler)
+	router.Get("/filters/:name/stats", handlers.StatsHandler)
+	router.Post("/filters/:name/merge", handlers.MergeHandler)
+	router.Get("/filters/:name/export", handlers.ExportHandler)
+	router.Put("/filters/:name/import", handlers.ImportHandler)
+	router.Post("/filters/:name/rekey", handlers.RekeyHandler)
+	router.Delete("/filters/:name/reset", handlers.ResetHandler)
+
+	// List every alias
+	// Returns "aliases", the name of every alias and the filter it points at,
+	// ordered by name.
+	router.Get("/aliases", handlers.ListAliasesHandler)
+
+	// Get the filter an alias points at
+	router.Get("/aliases/:alias", handlers.GetAliasHandler)
+
+	// Point an alias at a filter, e.g. users-current at users-2026-10
+	// Creates the alias (201) or swaps it in one step (200), so requests
+	// are served by either the old or the new filter and never fail. Returns
+	// 404 for an unknown filter and 409 if a filter is named like the alias.
+	// Body:
+	// {
+	//   "filter": "users-2026-10" // name of the filter, not another alias
+	// }
+	// Returns:
+	// {
+	//   "alias": "users-current",
+	//   "filter": "users-2026-10",
+	//   "previous": "users-2026-09" // filter the alias pointed at, "" if new
+	// }
+	router.Put("/aliases/:alias", handlers.SetAliasHandler)
+
+	// Delete an alias, the filter it points at is kept
+	router.Delete("/aliases/:alias", handlers.DeleteAliasHandler)
+
+	// Get the memory held by the filters of the tenant of the request
+	// Returns:
+	// {
+	//   "limit_bytes": 1073741824, // MEMORY_LIMIT, 0 for no limit
+	//   "used_bytes": 2400000, // memory booked by the filters of every tenant
+	//   "filters": [{"tenant": "default", "name": "default", "type": "standard", "bytes": 1200000, "booked_bytes": 1200000, "hibernated": false}, ...]
+	// }
+	router.Get("/memory", handlers.MemoryHandler)
+
+	// Get the statistics of the tenant of the request
+	// Returns:
+	// {
+	//   "tenant": "team-a", // name of the tenant
+	//   "filters": 3, // number of filters the tenant holds
+	//   "bits": 28755000, // memory booked by the filters of the tenant in bits
+	//   "ops": 120000, // item operations allowed, a batch counts every item
+	//   "throttled_ops": 500, // item operations refused by the rate limit
+	//   "limits": {"max_filters": 10, "max_bits": 0, "ops_per_second": 1000} // 0 for no limit
+	// }
+	router.Get("/tenant", handlers.TenantHandler)
 }
@@ -0,0 +1,187 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sync/atomic"
+)
+
+// BitStore is the storage backing the bit array of a bloom filter.
+// Implementations only need to support setting, testing and clearing bits,
+// which lets alternative stores (mmap, compressed, atomic) be dropped in
+// without touching Add, Exists or Clear. A BloomFilter calls Set and Test
+// concurrently, so implementations must be safe for concurrent use.
+type BitStore interface {
+	// Set sets the bit at index to 1 and reports whether it was 0 before
+	Set(index uint64) bool
+	// Test reports whether the bit at index is set
+	Test(index uint64) bool
+	// Clear resets every bit to 0
+	Clear()
+	// Len returns the number of bits in the store
+	Len() uint64
+}
+
+// wordBits is the number of bits held by a single word of a PackedBitStore
+const wordBits = 64
+
+// PackedBitStore is a BitStore that packs 64 bits into every uint64 word,
+// so a filter of m bits uses m/8 bytes of memory. Words are read and written
+// atomically, so it is safe for concurrent use without a lock.
+type PackedBitStore struct {
+	// number of addressable bits
+	size uint64
+
+	// packed bits, least significant bit first
+	words []uint64
+}
+
+// NewPackedBitStore Creates a new PackedBitStore holding size bits
+// parameters:
+//
+//	size	: number of bits in the store
+//
+// returns:
+//
+//	*PackedBitStore	: pointer to the PackedBitStore struct
+func NewPackedBitStore(size uint64) *PackedBitStore {
+	return &PackedBitStore{
+		size:  size,
+		words: make([]uint64, (size+wordBits-1)/wordBits),
+	}
+}
+
+// storeBytes Returns the memory held by a bit store, one bit per bit for
+// stores that do not tell
+func storeBytes(store BitStore) uint64 {
+	if packed, ok := store.(*PackedBitStore); ok {
+		return uint64(len(packed.words)) * 8
+	}
+	return (store.Len() + 7) / 8
+}
+
+// NewPackedBitStoreFromBytes Creates a new PackedBitStore holding size bits
+// from little-endian words as returned by Bytes. Bits beyond size are dropped.
+// parameters:
+//
+//	size	: number of bits in the store
+//	data	: packed bits, 8 bytes per word
+//
+// returns:
+//
+//	*PackedBitStore	: pointer to the PackedBitStore struct
+//	error			: error if data does not hold exactly the words of size bits
+func NewPackedBitStoreFromBytes(size uint64, data []byte) (*PackedBitStore, error) {
+	s := NewPackedBitStore(size)
+	if uint64(len(data)) != uint64(len(s.words))*8 {
+		return nil, fmt.Errorf("bloom: %d bits need %d bytes, got %d", size, len(s.words)*8, len(data))
+	}
+
+	for i := range s.words {
+		s.words[i] = binary.LittleEndian.Uint64(data[i*8:])
+	}
+	if tail := size % wordBits; tail != 0 {
+		s.words[len(s.words)-1] &= 1<<tail - 1
+	}
+	return s, nil
+}
+
+// Bytes Returns the packed bits as little-endian words
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	[]byte	: packed bits, 8 bytes per word
+func (s *PackedBitStore) Bytes() []byte {
+	data := make([]byte, len(s.words)*8)
+	for i := range s.words {
+		binary.LittleEndian.PutUint64(data[i*8:], atomic.LoadUint64(&s.words[i]))
+	}
+	return data
+}
+
+func (s *PackedBitStore) Set(index uint64) bool {
+	word := &s.words[index/wordBits]
+	mask := uint64(1) << (index % wordBits)
+
+	// atomic OR, a CAS loop since sync/atomic has no OrUint64 before go1.23
+	for {
+		old := atomic.LoadUint64(word)
+		if old&mask != 0 {
+			return false
+		}
+		if atomic.CompareAndSwapUint64(word, old, old|mask) {
+			return true
+		}
+	}
+}
+
+func (s *PackedBitStore) Test(index uint64) bool {
+	return atomic.LoadUint64(&s.words[index/wordBits])&(1<<(index%wordBits)) != 0
+}
+
+func (s *PackedBitStore) Clear() {
+	for i := range s.words {
+		atomic.StoreUint64(&s.words[i], 0)
+	}
+}
+
+func (s *PackedBitStore) Len() uint64 {
+	return s.size
+}
+
+// or ORs the words of other into the store, word by word atomically
+func (s *PackedBitStore) or(other *PackedBitStore) {
+	for i := range s.words {
+		src := atomic.LoadUint64(&other.words[i])
+		for {
+			old := atomic.LoadUint64(&s.words[i])
+			if old|src == old || atomic.CompareAndSwapUint64(&s.words[i], old, old|src) {
+				break
+			}
+		}
+	}
+}
+
+// and ANDs the words of other into the store, word by word atomically
+func (s *PackedBitStore) and(other *PackedBitStore) {
+	for i := range s.words {
+		src := atomic.LoadUint64(&other.words[i])
+		for {
+			old := atomic.LoadUint64(&s.words[i])
+			if old&src == old || atomic.CompareAndSwapUint64(&s.words[i], old, old&src) {
+				break
+			}
+		}
+	}
+}
+
+// count returns the number of set bits
+func (s *PackedBitStore) count() uint64 {
+	var n uint64
+	for i := range s.words {
+		n += uint64(bits.OnesCount64(atomic.LoadUint64(&s.words[i])))
+	}
+	return n
+}
+
+func (s *PackedBitStore) String() string {
+	return fmt.Sprintf("PackedBitStore{size: %d, words: %d}", s.size, len(s.words))
+}
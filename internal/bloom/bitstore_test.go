@@ -0,0 +1,50 @@
+package bloom
+
+import "testing"
+
+func TestPackedBitStore(t *testing.T) {
+	store := NewPackedBitStore(130)
+	if store.Len() != 130 {
+		t.Fatalf("Expected length 130, got %d", store.Len())
+	}
+	if len(store.words) != 3 {
+		t.Fatalf("Expected 3 words, got %d", len(store.words))
+	}
+
+	set := []uint64{0, 1, 63, 64, 127, 129}
+	for _, idx := range set {
+		if !store.Set(idx) {
+			t.Fatalf("Expected bit %d to be newly set", idx)
+		}
+		if store.Set(idx) {
+			t.Fatalf("Expected bit %d to be set already", idx)
+		}
+	}
+
+	for idx := range store.Len() {
+		want := false
+		for _, s := range set {
+			if s == idx {
+				want = true
+			}
+		}
+		if got := store.Test(idx); got != want {
+			t.Fatalf("Bit %d: expected %v, got %v", idx, want, got)
+		}
+	}
+
+	store.Clear()
+	for idx := range store.Len() {
+		if store.Test(idx) {
+			t.Fatalf("Expected bit %d to be cleared", idx)
+		}
+	}
+}
+
+func TestPackedBitStoreMemory(t *testing.T) {
+	// 1M bits must fit in 1M/64 words, i.e. 125KB instead of 1MB of bools
+	store := NewPackedBitStore(1_000_000)
+	if got, want := len(store.words), 15625; got != want {
+		t.Fatalf("Expected %d words, got %d", want, got)
+	}
+}
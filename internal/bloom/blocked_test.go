@@ -0,0 +1,83 @@
+package bloom
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func newBlockedFilter(t *testing.T, n int, p float64) *BlockedBloomFilter {
+	t.Helper()
+	filter, err := NewBlocked(CalculateOptimalParameters(n, p))
+	if err != nil {
+		t.Fatalf("Failed to create blocked filter: %v", err)
+	}
+	return filter
+}
+
+func TestBlockedAddAndExists(t *testing.T) {
+	items := test.GenerateStringsOfLength(10, 1000)
+	filter := newBlockedFilter(t, len(items), 0.01)
+
+	for _, item := range items {
+		filter.Add(item)
+	}
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+
+	filter.Clear()
+	if filter.Exists(items[0]) {
+		t.Fatalf("Expected item %s to be cleared", items[0])
+	}
+}
+
+func TestBlockedLayout(t *testing.T) {
+	filter := newBlockedFilter(t, 1000, 0.01)
+
+	if filter.params.Size%blockBits != 0 {
+		t.Fatalf("Expected the size to be whole blocks, got %d bits", filter.params.Size)
+	}
+	if addr := uintptr(unsafe.Pointer(&filter.words[0])); addr%64 != 0 {
+		t.Fatalf("Expected blocks to be cache line aligned, got address %x", addr)
+	}
+
+	// every bit of an item must land in a single block
+	filter.Add("apple")
+	touched := 0
+	for block := range filter.numBlocks {
+		for _, word := range filter.words[block*blockWords : (block+1)*blockWords] {
+			if word != 0 {
+				touched++
+				break
+			}
+		}
+	}
+	if touched != 1 {
+		t.Fatalf("Expected one block to be touched, got %d", touched)
+	}
+}
+
+func TestBlockedFalsePositiveRate(t *testing.T) {
+	n, p := 10_000, 0.01
+	filter := newBlockedFilter(t, n, p)
+	for _, item := range test.GenerateStringsOfLength(12, n) {
+		filter.Add(item)
+	}
+
+	testN := 50_000
+	falsePositives := 0
+	for _, item := range test.GenerateStringsOfLength(10, testN) {
+		if filter.Exists(item) {
+			falsePositives++
+		}
+	}
+
+	// blocking costs some accuracy, but it must stay close to the target
+	if rate := float64(falsePositives) / float64(testN); rate > 2*p {
+		t.Fatalf("Expected false positive rate close to %v, got %v", p, rate)
+	}
+}
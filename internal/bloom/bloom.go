@@ -16,22 +16,48 @@ package bloom
 
 import (
 	"fmt"
-	"hash"
-	"math/rand"
 	"sync"
-
-	"github.com/spaolacci/murmur3"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
-var Filter *BloomFilter
-
 type Parameters struct {
 	// Size of the bloom filter in bits
-	Size uint `json:"size"`
+	Size uint64 `json:"size"`
 	// Number of hash functions used
 	NumHashFunctions uint8 `json:"num_hash_functions"`
 	// Estimated false positive rate
 	FalsePositiveRate float64 `json:"false_positive_rate"`
+	// Number of items the filter was sized for
+	Capacity uint64 `json:"capacity"`
+	// Master seed the hash function seeds are derived from. Filters built
+	// from identical parameters hash keys to identical bits.
+	Seed uint64 `json:"seed"`
+	// Hash algorithm the bit indices are derived from
+	HashAlgorithm HashAlgorithm `json:"hash_algorithm"`
+	// Type of the filter
+	Type FilterType `json:"type"`
+	// Width in bits of the counters of a counting filter
+	CounterWidth uint8 `json:"counter_width,omitempty"`
+	// Capacity multiplier of each new layer of a scalable filter
+	GrowthFactor uint8 `json:"growth_factor,omitempty"`
+	// False positive rate multiplier of each new layer of a scalable filter
+	TighteningRatio float64 `json:"tightening_ratio,omitempty"`
+	// Number of live generations of a rotating filter
+	Generations uint8 `json:"generations,omitempty"`
+	// Age after which a rotating filter starts a new generation, in nanoseconds
+	RotationInterval time.Duration `json:"rotation_interval,omitempty"`
+	// Number of items after which a rotating filter starts a new generation
+	RotationItems uint64 `json:"rotation_items,omitempty"`
+	// Width in bits of the fingerprints of a cuckoo filter
+	FingerprintBits uint8 `json:"fingerprint_bits,omitempty"`
+	// Number of fingerprints per bucket of a cuckoo filter
+	BucketSize uint8 `json:"bucket_size,omitempty"`
+	// Number of relocations before an insert into a cuckoo filter fails
+	MaxKicks uint16 `json:"max_kicks,omitempty"`
+	// Number of independently locked shards of a sharded filter
+	Shards uint16 `json:"shards,omitempty"`
 }
 
 type Statistics struct {
@@ -39,59 +65,117 @@ type Statistics struct {
 	AddedItems uint64 `json:"added_items"`
 	// Number of items checked in the bloom filter
 	CheckedItems uint64 `json:"checked_items"`
+	// Number of set bits, or of non-zero counters of a counting filter
+	SetBits uint64 `json:"set_bits,omitempty"`
+	// Estimated number of distinct items in the filter. Unlike AddedItems it
+	// does not count duplicates, so it tells when a filter is over capacity.
+	EstimatedItems float64 `json:"estimated_items"`
+	// Estimated probability that an item never added is reported to exist
+	EstimatedFalsePositiveRate float64 `json:"estimated_false_positive_rate"`
+	// Number of items removed from a counting filter
+	RemovedItems uint64 `json:"removed_items,omitempty"`
+	// Number of items a cuckoo filter was too full to insert
+	FailedInserts uint64 `json:"failed_inserts,omitempty"`
+	// Per-layer statistics of a scalable filter
+	Layers []LayerStatistics `json:"layers,omitempty"`
+	// Per-generation statistics of a rotating filter, oldest first
+	Generations []GenerationStatistics `json:"generations,omitempty"`
+	// Per-shard statistics of a sharded filter
+	Shards []ShardStatistics `json:"shards,omitempty"`
 }
 
+// BloomFilter is a classic bloom filter. It takes no lock on Add and Exists:
+// bits are set with atomic word operations, hashing keeps no shared state and
+// the statistics are atomic counters, so any number of readers and writers
+// proceed in parallel.
 type BloomFilter struct {
 	// parameters for the bloom filter
 	params Parameters
 
 	// statistics for the bloom filter
-	stats Statistics
+	added   atomic.Uint64
+	checked atomic.Uint64
+
+	// bit store and hasher, replaced as a whole by Rekey
+	state atomic.Pointer[bloomState]
+
+	// mutex serializing Rekey
+	mu *sync.Mutex
+
+	// striped locks serializing AddIfAbsent of items with the same hash
+	stripes []sync.Mutex
+}
+
+// addStripes is the number of striped locks of a BloomFilter
+const addStripes = 256
 
-	// bit array to store the bloom filter
-	bitArray []bool // TODO: use a bit array instead of a slice of bools
+// stripeBytes is the memory held by the striped locks of a BloomFilter
+const stripeBytes = addStripes * uint64(unsafe.Sizeof(sync.Mutex{}))
 
-	// hash functions to use
-	hashFuncList []hash.Hash32
+// bloomState is the part of a BloomFilter that Rekey swaps atomically
+type bloomState struct {
+	// bit store backing the bloom filter
+	bits BitStore
 
-	// TODO: use mutex for concurrent access
-	// mutex for concurrent access
-	mu *sync.RWMutex
+	// hasher the bit indices are derived from
+	hasher Hasher
 
-	// wait group for concurrent operations
-	wg *sync.WaitGroup
+	// size of the filter in bits and number of hash functions
+	size, k uint64
+
+	// number of bits set in the bit store
+	setBits atomic.Uint64
+
+	// state a Rekey rebuilds the filter into, adds are mirrored into it
+	next atomic.Pointer[bloomState]
 }
 
 // New Creates a new BloomFilter based on the size and number of hash functions
+// backed by a PackedBitStore
 // parameters:
 //
 //	size			: number of bits in the bit array
 //	hashFunctions	: number of times to hash the input
+//	opts			: optional behaviour, e.g. WithKey
 //
 // returns:
 //
 //	*BloomFilter	: pointer to the BloomFilter struct
-func New(params Parameters) *BloomFilter {
-	// create hash functions
-	hashFuncList := make([]hash.Hash32, params.NumHashFunctions)
-	for i := range params.NumHashFunctions {
-		hashFuncList[i] = murmur3.New32WithSeed(rand.Uint32())
+func New(params Parameters, opts ...Option) *BloomFilter {
+	return NewWithStore(params, NewPackedBitStore(params.Size), opts...)
+}
+
+// NewWithStore Creates a new BloomFilter that keeps its bits in the given store
+// parameters:
+//
+//	params	: parameters of the bloom filter
+//	store	: bit store holding at least params.Size bits
+//	opts	: optional behaviour, e.g. WithKey
+//
+// returns:
+//
+//	*BloomFilter	: pointer to the BloomFilter struct
+func NewWithStore(params Parameters, store BitStore, opts ...Option) *BloomFilter {
+	if store.Len() < params.Size {
+		panic(fmt.Sprintf("bloom: bit store holds %d bits, need %d", store.Len(), params.Size))
 	}
 
-	return &BloomFilter{
-		params:       params,
-		bitArray:     make([]bool, params.Size),
-		hashFuncList: hashFuncList,
-		stats:        Statistics{},
-		mu:           &sync.RWMutex{},
+	hasher, err := newFilterHasher(params, buildOptions(opts))
+	if err != nil {
+		panic(err.Error())
 	}
-}
+	if params.HashAlgorithm == "" {
+		params.HashAlgorithm = HashMurmur3
+	}
+	params.Type = TypeStandard
 
-func Init(estimatedKeyCount int, falsePositivePct float64) {
-	if Filter == nil {
-		params := CalculateOptimalParameters(estimatedKeyCount, falsePositivePct)
-		Filter = New(params)
+	b := &BloomFilter{
+		params:  params,
+		mu:      &sync.Mutex{},
+		stripes: make([]sync.Mutex, addStripes),
 	}
+	b.state.Store(newBloomState(params, store, hasher))
+	return b
 }
 
 // Add Adds an item to the bloom filter
@@ -103,14 +187,9 @@ func Init(estimatedKeyCount int, falsePositivePct float64) {
 //
 //	none
 func (b *BloomFilter) Add(item string) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	b.stats.AddedItems++
-	idx := b.doHash(item)
-	for _, index := range idx {
-		b.bitArray[index] = true
-	}
+	b.added.Add(1)
+	// hash and set through the same state, a rekey swaps both together
+	b.state.Load().addItem(stringBytes(item))
 }
 
 // Exists Checks if an item is in the bloom filter
@@ -122,19 +201,99 @@ func (b *BloomFilter) Add(item string) {
 //
 //	bool	: true if the item is in the bloom filter, false otherwise
 func (b *BloomFilter) Exists(item string) bool {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-	b.stats.CheckedItems++
-	idx := b.doHash(item)
-	for _, index := range idx {
-		if !b.bitArray[index] {
-			return false
-		}
+	b.checked.Add(1)
+	st := b.state.Load()
+	return st.exists(st.hasher.Sum128(stringBytes(item)))
+}
+
+// AddBytes Adds a binary item to the bloom filter, see BytesFilter
+// parameters:
+//
+//	item	: item to add to the bloom filter
+//
+// returns:
+//
+//	none
+func (b *BloomFilter) AddBytes(item []byte) {
+	b.Add(bytesString(item))
+}
+
+// ExistsBytes Checks if a binary item is in the bloom filter, see BytesFilter
+// parameters:
+//
+//	item	: item to check in the bloom filter
+//
+// returns:
+//
+//	bool	: true if the item is in the bloom filter, false otherwise
+func (b *BloomFilter) ExistsBytes(item []byte) bool {
+	return b.Exists(bytesString(item))
+}
+
+// AddMany Adds a batch of items to the bloom filter, loading its state and
+// updating its statistics once per batch
+// parameters:
+//
+//	items	: items to add to the bloom filter
+//
+// returns:
+//
+//	none
+func (b *BloomFilter) AddMany(items []string) {
+	b.added.Add(uint64(len(items)))
+	st := b.state.Load()
+	for _, item := range items {
+		st.addItem(stringBytes(item))
 	}
-	return true
 }
 
-// Clear Clears the bloom filter
+// ExistsMany Checks a batch of items in the bloom filter, loading its state and
+// updating its statistics once per batch
+// parameters:
+//
+//	items	: items to check in the bloom filter
+//
+// returns:
+//
+//	[]bool	: for every item, true if it is in the bloom filter, in the order of items
+func (b *BloomFilter) ExistsMany(items []string) []bool {
+	b.checked.Add(uint64(len(items)))
+	st := b.state.Load()
+	exists := make([]bool, len(items))
+	for i, item := range items {
+		exists[i] = st.exists(st.hasher.Sum128(stringBytes(item)))
+	}
+	return exists
+}
+
+// AddIfAbsent Adds an item to the bloom filter and reports whether it was
+// already present. Setting the bits is lock-free, but two callers adding the
+// same item could each set some of its bits first and both see it as new, so
+// callers are serialized by a lock striped on the hash of the item.
+// parameters:
+//
+//	item	: item to add to the bloom filter
+//
+// returns:
+//
+//	bool	: true if the item was already in the bloom filter, false otherwise
+func (b *BloomFilter) AddIfAbsent(item string) bool {
+	st := b.state.Load()
+	h1, h2 := st.hasher.Sum128(stringBytes(item))
+
+	stripe := &b.stripes[h1%uint64(len(b.stripes))]
+	stripe.Lock()
+	defer stripe.Unlock()
+
+	b.checked.Add(1)
+	b.added.Add(1)
+	added := st.add(h1, h2)
+	st.mirror(stringBytes(item))
+	return !added
+}
+
+// Clear Clears the bloom filter. Items added concurrently with Clear may or
+// may not survive it.
 // parameters:
 //
 //	none
@@ -143,37 +302,64 @@ func (b *BloomFilter) Exists(item string) bool {
 //
 //	none
 func (b *BloomFilter) Clear() {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	st := b.state.Load()
+	st.bits.Clear()
+	st.setBits.Store(0)
+}
 
-	b.bitArray = make([]bool, b.params.Size)
+// FillRatio Returns the fraction of bits that are set
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	float64	: set bits divided by the size of the filter
+func (b *BloomFilter) FillRatio() float64 {
+	return float64(b.state.Load().setBits.Load()) / float64(b.params.Size)
 }
 
-// doHash Hashes the input string using the hash functions
+// SizeInBytes Returns the memory held by the bit store and the striped locks
+// of the bloom filter
 // parameters:
 //
-//	input			: input string to hash
-//	hashFunctions	: number of hash functions to use
+//	none
 //
 // returns:
 //
-//	[]int	: slice of indices to set in the bit array
-func (b *BloomFilter) doHash(input string) []uint {
-	idx := make([]uint, 0, b.params.NumHashFunctions)
+//	uint64	: size of the bloom filter in bytes
+func (b *BloomFilter) SizeInBytes() uint64 {
+	return storeBytes(b.state.Load().bits) + stripeBytes
+}
 
-	for _, hashFunc := range b.hashFuncList {
-		hashFunc.Reset()
-		hashFunc.Write([]byte(input))
-		hashValue := hashFunc.Sum32()
-		index := uint(hashValue) % b.params.Size
-		idx = append(idx, index)
-	}
+// addHashed Sets the bits of an item hashed by doHash
+func (b *BloomFilter) addHashed(h1, h2 uint64) {
+	b.state.Load().add(h1, h2)
+}
+
+// existsHashed Tests the bits of an item hashed by doHash
+func (b *BloomFilter) existsHashed(h1, h2 uint64) bool {
+	return b.state.Load().exists(h1, h2)
+}
 
-	return idx
+// doHash Hashes the input string once with the filter's hasher. The bit
+// indices are derived from the two 64-bit halves by double hashing
+// (Kirsch–Mitzenmacher), which keeps the false positive rate of k
+// independent hash functions.
+// parameters:
+//
+//	input	: input string to hash
+//
+// returns:
+//
+//	uint64	: lower half of the 128-bit hash
+//	uint64	: upper half of the 128-bit hash
+func (b *BloomFilter) doHash(input string) (uint64, uint64) {
+	return b.state.Load().hasher.Sum128(stringBytes(input))
 }
 
 func (b *BloomFilter) String() string {
-	return fmt.Sprintf("BloomFilter{size: %d, hashFunctions: %d, bitArray: %v}", b.params.Size, b.params.NumHashFunctions, b.bitArray)
+	return fmt.Sprintf("BloomFilter{size: %d, hashFunctions: %d, bits: %v}", b.params.Size, b.params.NumHashFunctions, b.state.Load().bits)
 }
 
 func (b *BloomFilter) GetParameters() Parameters {
@@ -181,8 +367,74 @@ func (b *BloomFilter) GetParameters() Parameters {
 }
 
 func (b *BloomFilter) GetStatistics() Statistics {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
+	st := b.state.Load()
+	stats := Statistics{
+		AddedItems:   b.added.Load(),
+		CheckedItems: b.checked.Load(),
+	}
+	stats.estimate(st.size, st.k, st.setBits.Load())
+	return stats
+}
+
+// estimate Sets the set bits and the estimates derived from them
+func (s *Statistics) estimate(size, k, setBits uint64) {
+	s.SetBits = setBits
+	s.EstimatedItems = EstimateCardinality(float64(size), float64(k), float64(setBits))
+	s.EstimatedFalsePositiveRate = EstimateFalsePositiveRateFromBits(float64(size), float64(k), float64(setBits))
+}
+
+// addEstimates Adds the estimates of one of several filters that are all
+// checked for every item, as the layers of a scalable filter. An item is a
+// false positive if any of them reports it.
+func (s *Statistics) addEstimates(part Statistics) {
+	s.SetBits += part.SetBits
+	s.EstimatedItems += part.EstimatedItems
+	s.EstimatedFalsePositiveRate = 1 - (1-s.EstimatedFalsePositiveRate)*(1-part.EstimatedFalsePositiveRate)
+}
+
+func newBloomState(params Parameters, store BitStore, hasher Hasher) *bloomState {
+	return &bloomState{
+		bits:   store,
+		hasher: hasher,
+		size:   params.Size,
+		k:      uint64(params.NumHashFunctions),
+	}
+}
 
-	return b.stats
+// add Sets the bits g_i of a hashed item, see location, and reports
+// whether any of them was newly set
+func (st *bloomState) add(h1, h2 uint64) bool {
+	added := false
+	for i := range st.k {
+		if st.bits.Set(location(h1, h2, i, st.size)) {
+			st.setBits.Add(1)
+			added = true
+		}
+	}
+	return added
+}
+
+// addItem Sets the bits of an item, see add, and mirrors it into the states a
+// Rekey rebuilds into
+func (st *bloomState) addItem(item []byte) {
+	st.add(st.hasher.Sum128(item))
+	st.mirror(item)
+}
+
+// mirror Sets the bits of an item already added to st in every state a Rekey
+// started since rebuilds into, hashed with the key of that state
+func (st *bloomState) mirror(item []byte) {
+	for next := st.next.Load(); next != nil; next = next.next.Load() {
+		next.add(next.hasher.Sum128(item))
+	}
+}
+
+// exists Tests the bits g_i of a hashed item, see location
+func (st *bloomState) exists(h1, h2 uint64) bool {
+	for i := range st.k {
+		if !st.bits.Test(location(h1, h2, i, st.size)) {
+			return false
+		}
+	}
+	return true
 }
@@ -1,8 +1,13 @@
 package bloom
 
 import (
+	"fmt"
+	"hash"
+	"strconv"
 	"testing"
 
+	"github.com/spaolacci/murmur3"
+
 	"github.com/vinit-chauhan/go-bloomservice/test"
 )
 
@@ -38,3 +43,216 @@ func BenchmarkBloomFilter_FalsePositives(b *testing.B) {
 	}
 	b.ResetTimer()
 }
+
+// legacyFilter reproduces the previous index derivation, k independent
+// murmur3-32 passes over the key, as a baseline for the double hashing scheme
+type legacyFilter struct {
+	params       Parameters
+	bits         *PackedBitStore
+	hashFuncList []hash.Hash32
+}
+
+func newLegacyFilter(params Parameters) *legacyFilter {
+	hashFuncList := make([]hash.Hash32, params.NumHashFunctions)
+	for i := range params.NumHashFunctions {
+		hashFuncList[i] = murmur3.New32WithSeed(deriveSeed(params.Seed, uint64(i)))
+	}
+
+	return &legacyFilter{
+		params:       params,
+		bits:         NewPackedBitStore(params.Size),
+		hashFuncList: hashFuncList,
+	}
+}
+
+func (l *legacyFilter) doHash(input string) []uint64 {
+	idx := make([]uint64, 0, l.params.NumHashFunctions)
+	for _, hashFunc := range l.hashFuncList {
+		hashFunc.Reset()
+		hashFunc.Write([]byte(input))
+		idx = append(idx, uint64(hashFunc.Sum32())%l.params.Size)
+	}
+	return idx
+}
+
+func (l *legacyFilter) Add(item string) {
+	for _, index := range l.doHash(item) {
+		l.bits.Set(index)
+	}
+}
+
+func (l *legacyFilter) Exists(item string) bool {
+	for _, index := range l.doHash(item) {
+		if !l.bits.Test(index) {
+			return false
+		}
+	}
+	return true
+}
+
+type benchFilter interface {
+	Add(item string)
+	Exists(item string) bool
+}
+
+func benchSchemes(params Parameters) map[string]func() benchFilter {
+	return map[string]func() benchFilter{
+		"double_hashing": func() benchFilter { return New(params) },
+		"k_murmur3_32":   func() benchFilter { return newLegacyFilter(params) },
+	}
+}
+
+func BenchmarkBloomFilter_Add(b *testing.B) {
+	params := CalculateOptimalParameters(1_000_000, 0.01)
+	items := test.GenerateStringsOfLength(16, 1024)
+
+	for name, create := range benchSchemes(params) {
+		b.Run(name, func(b *testing.B) {
+			filter := create()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				filter.Add(items[i%len(items)])
+			}
+		})
+	}
+}
+
+func BenchmarkBloomFilter_Exists(b *testing.B) {
+	params := CalculateOptimalParameters(1_000_000, 0.01)
+	items := test.GenerateStringsOfLength(16, 1024)
+
+	for name, create := range benchSchemes(params) {
+		b.Run(name, func(b *testing.B) {
+			filter := create()
+			for _, item := range items[:len(items)/2] {
+				filter.Add(item)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				filter.Exists(items[i%len(items)])
+			}
+		})
+	}
+}
+
+func BenchmarkBloomFilter_FalsePositivesByScheme(b *testing.B) {
+	n := 100_000     // Number of items to add
+	testN := 200_000 // Number of items to test
+
+	params := CalculateOptimalParameters(n, 0.01)
+	items := test.GenerateStringsOfLength(12, n)
+	testItems := test.GenerateStringsOfLength(10, testN)
+
+	rates := map[string]float64{}
+	for name, create := range benchSchemes(params) {
+		filter := create()
+		for _, item := range items {
+			filter.Add(item)
+		}
+
+		falsePositives := 0
+		for _, item := range testItems {
+			if filter.Exists(item) {
+				falsePositives++
+			}
+		}
+		rates[name] = float64(falsePositives) / float64(testN) * 100
+		b.ReportMetric(rates[name], name+"_fp_pct")
+	}
+	b.Logf("False positivity rate: double hashing %.3f%%, k murmur3-32 %.3f%%", rates["double_hashing"], rates["k_murmur3_32"])
+
+	// Double hashing must not be measurably worse than k independent hashes;
+	// allow for the sampling noise of testN lookups.
+	tolerancePct := 0.1
+	if rates["double_hashing"] > rates["k_murmur3_32"]+tolerancePct {
+		b.Fatalf("Double hashing false positivity rate %.3f%% exceeds baseline %.3f%%", rates["double_hashing"], rates["k_murmur3_32"])
+	}
+}
+
+func BenchmarkBloomFilter_ExistsByHashAlgorithm(b *testing.B) {
+	items := test.GenerateStringsOfLength(16, 1024)
+
+	for _, algorithm := range HashAlgorithms {
+		b.Run(string(algorithm), func(b *testing.B) {
+			params := CalculateOptimalParameters(1_000_000, 0.01)
+			params.HashAlgorithm = algorithm
+			filter := New(params)
+			for _, item := range items[:len(items)/2] {
+				filter.Add(item)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				filter.Exists(items[i%len(items)])
+			}
+		})
+	}
+}
+
+// BenchmarkBlocked_Exists compares the lookup latency and false positive rate
+// of the classic and the blocked filter on large filters. Run with -short to
+// skip the 100M key filters, which take a while to fill.
+func BenchmarkBlocked_Exists(b *testing.B) {
+	for _, n := range []int{10_000_000, 100_000_000} {
+		if n > 10_000_000 && testing.Short() {
+			continue
+		}
+
+		params := CalculateOptimalParameters(n, 0.01)
+		blocked, err := NewBlocked(params)
+		if err != nil {
+			b.Fatalf("Failed to create blocked filter: %v", err)
+		}
+		filters := []struct {
+			name   string
+			filter ProbabilisticFilter
+		}{
+			{"standard", New(params)},
+			{"blocked", blocked},
+		}
+
+		for _, f := range filters {
+			for i := range n {
+				f.filter.Add(strconv.Itoa(i))
+			}
+
+			testN := 100_000
+			falsePositives := 0
+			for i := range testN {
+				if f.filter.Exists("absent-" + strconv.Itoa(i)) {
+					falsePositives++
+				}
+			}
+			fpPct := float64(falsePositives) / float64(testN) * 100
+
+			lookups := test.GenerateStringsOfLength(16, 4096)
+			b.Run(fmt.Sprintf("%s/%dM", f.name, n/1_000_000), func(b *testing.B) {
+				for i := range b.N {
+					f.filter.Exists(lookups[i%len(lookups)])
+				}
+				b.ReportMetric(fpPct, "fp_pct")
+			})
+		}
+	}
+}
+
+func BenchmarkBloomFilter_Parallel(b *testing.B) {
+	params := CalculateOptimalParameters(1_000_000, 0.01)
+	items := test.GenerateStringsOfLength(16, 1024)
+	filter := New(params)
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			// one write for every three lookups
+			if i%4 == 0 {
+				filter.Add(items[i%len(items)])
+			} else {
+				filter.Exists(items[i%len(items)])
+			}
+			i++
+		}
+	})
+}
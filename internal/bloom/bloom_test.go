@@ -1,6 +1,7 @@
 package bloom
 
 import (
+	"math"
 	"testing"
 
 	"github.com/vinit-chauhan/go-bloomservice/test"
@@ -9,11 +10,12 @@ import (
 func TestNew(t *testing.T) {
 	params := CalculateOptimalParameters(100, 0.01)
 	filter := New(params)
-	if len(filter.bitArray) != int(params.Size) {
-		t.Errorf("Expected size %d, got %d", params.Size, len(filter.bitArray))
+	bits := filter.state.Load().bits
+	if bits.Len() != params.Size {
+		t.Errorf("Expected size %d, got %d", params.Size, bits.Len())
 	}
-	if len(filter.hashFuncList) != int(params.NumHashFunctions) {
-		t.Errorf("Expected %d hash functions, got %d", params.NumHashFunctions, len(filter.hashFuncList))
+	if filter.state.Load().hasher == nil {
+		t.Errorf("Expected a %s hasher", params.HashAlgorithm)
 	}
 }
 
@@ -70,9 +72,123 @@ func TestClear(t *testing.T) {
 
 	filter.Clear()
 
-	for bit := range filter.bitArray {
-		if filter.bitArray[bit] {
+	bits := filter.state.Load().bits
+	for bit := range bits.Len() {
+		if bits.Test(bit) {
 			t.Fatalf("Expected bit array to be cleared, but bit %d is still set", bit)
 		}
 	}
 }
+
+func TestDeterministicSeed(t *testing.T) {
+	items := test.GenerateStringsOfLength(10, 100)
+	params := CalculateOptimalParameters(len(items), 0.01)
+	params.Seed = 42
+
+	first, second := New(params), New(params)
+	for _, item := range items {
+		first.Add(item)
+		second.Add(item)
+	}
+
+	firstBits, secondBits := first.state.Load().bits, second.state.Load().bits
+	for bit := range firstBits.Len() {
+		if firstBits.Test(bit) != secondBits.Test(bit) {
+			t.Fatalf("Expected filters with the same seed to set the same bits, bit %d differs", bit)
+		}
+	}
+
+	params.Seed = 43
+	other := New(params)
+	h1, h2 := first.doHash("apple")
+	o1, o2 := other.doHash("apple")
+	if h1 == o1 && h2 == o2 {
+		t.Fatalf("Expected filters with different seeds to hash differently")
+	}
+}
+
+func TestEstimatedItems(t *testing.T) {
+	n := 5000
+	filter := New(CalculateOptimalParameters(10_000, 0.01))
+	items := test.GenerateStringsOfLength(10, n)
+
+	// duplicates are counted by AddedItems but not by the estimate
+	for range 3 {
+		for _, item := range items {
+			filter.Add(item)
+		}
+	}
+
+	stats := filter.GetStatistics()
+	if stats.AddedItems != uint64(3*n) {
+		t.Fatalf("Expected %d added items, got %d", 3*n, stats.AddedItems)
+	}
+	if math.Abs(stats.EstimatedItems-float64(n)) > 0.03*float64(n) {
+		t.Fatalf("Expected about %d estimated items, got %f", n, stats.EstimatedItems)
+	}
+
+	expected := EstimateFalsePositiveRate(float64(filter.params.Size), float64(filter.params.NumHashFunctions), float64(n))
+	if math.Abs(stats.EstimatedFalsePositiveRate-expected) > 0.2*expected {
+		t.Fatalf("Expected an estimated false positive rate near %f, got %f", expected, stats.EstimatedFalsePositiveRate)
+	}
+
+	filter.Clear()
+	if stats := filter.GetStatistics(); stats.SetBits != 0 || stats.EstimatedItems != 0 {
+		t.Fatalf("Expected no estimated items after Clear, got %+v", stats)
+	}
+}
+
+func TestEstimateCardinalitySaturated(t *testing.T) {
+	if n := EstimateCardinality(100, 3, 100); math.IsInf(n, 0) || math.IsNaN(n) {
+		t.Fatalf("Expected a finite estimate for a saturated filter, got %f", n)
+	}
+	if p := EstimateFalsePositiveRateFromBits(100, 3, 100); p != 1 {
+		t.Fatalf("Expected a saturated filter to report every item, got %f", p)
+	}
+}
+
+// sparseBitStore is a map backed BitStore used to address filters too large
+// to allocate in tests, it is not safe for concurrent use
+type sparseBitStore struct {
+	size uint64
+	bits map[uint64]bool
+}
+
+func (s *sparseBitStore) Test(index uint64) bool { return s.bits[index] }
+func (s *sparseBitStore) Clear()                 { clear(s.bits) }
+func (s *sparseBitStore) Len() uint64            { return s.size }
+
+func (s *sparseBitStore) Set(index uint64) bool {
+	wasSet := s.bits[index]
+	s.bits[index] = true
+	return !wasSet
+}
+
+func TestIndicesBeyond32Bits(t *testing.T) {
+	params := Parameters{Size: 1 << 36, NumHashFunctions: 7}
+	store := &sparseBitStore{size: params.Size, bits: map[uint64]bool{}}
+	filter := NewWithStore(params, store)
+
+	items := test.GenerateStringsOfLength(10, 100)
+	for _, item := range items {
+		filter.Add(item)
+	}
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+
+	high := false
+	for index := range store.bits {
+		if index >= params.Size {
+			t.Fatalf("Index %d out of range", index)
+		}
+		if index > 1<<32 {
+			high = true
+		}
+	}
+	if !high {
+		t.Fatalf("Expected indices above 2^32 in a filter of %d bits", params.Size)
+	}
+}
@@ -0,0 +1,200 @@
+package bloom
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+// The tests in this file hammer a BloomFilter from many goroutines at once.
+// They are meant to be run with -race, which turns any unsynchronized access
+// into a failure.
+
+func stressWorkers() int {
+	return max(4, 2*runtime.GOMAXPROCS(0))
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	workers, perWorker := stressWorkers(), 2000
+	items := test.GenerateStringsOfLength(12, workers*perWorker)
+	filter := New(CalculateOptimalParameters(len(items), 0.01))
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func(batch []string) {
+			defer wg.Done()
+			for _, item := range batch {
+				filter.Add(item)
+			}
+		}(items[w*perWorker : (w+1)*perWorker])
+	}
+	wg.Wait()
+
+	// no bit set by a concurrent writer may be lost
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+
+	stats := filter.GetStatistics()
+	if stats.AddedItems != uint64(len(items)) || stats.CheckedItems != uint64(len(items)) {
+		t.Fatalf("Expected %d added and checked items, got %+v", len(items), stats)
+	}
+
+	// the set bit counter must agree with the bits actually set
+	st := filter.state.Load()
+	var set uint64
+	for bit := range st.bits.Len() {
+		if st.bits.Test(bit) {
+			set++
+		}
+	}
+	if got := st.setBits.Load(); got != set {
+		t.Fatalf("Expected %d set bits, counted %d", set, got)
+	}
+}
+
+func TestConcurrentAddAndExists(t *testing.T) {
+	workers, perWorker := stressWorkers(), 2000
+	present := test.GenerateStringsOfLength(12, perWorker)
+	added := test.GenerateStringsOfLength(12, workers*perWorker)
+	filter := New(CalculateOptimalParameters(len(present)+len(added), 0.01))
+	for _, item := range present {
+		filter.Add(item)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, workers)
+	for w := range workers {
+		wg.Add(2)
+		go func(batch []string) {
+			defer wg.Done()
+			for _, item := range batch {
+				filter.Add(item)
+			}
+		}(added[w*perWorker : (w+1)*perWorker])
+		go func() {
+			defer wg.Done()
+			// readers must never miss an item added before they started
+			for _, item := range present {
+				if !filter.Exists(item) {
+					errs <- item
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for item := range errs {
+		t.Errorf("Expected item %s to exist during concurrent writes", item)
+	}
+	if got := filter.GetStatistics().AddedItems; got != uint64(len(present)+len(added)) {
+		t.Fatalf("Expected %d added items, got %d", len(present)+len(added), got)
+	}
+}
+
+func TestConcurrentClearAndRekey(t *testing.T) {
+	workers := stressWorkers()
+	items := test.GenerateStringsOfLength(12, 1000)
+	filter := New(CalculateOptimalParameters(len(items), 0.01), WithKey([]byte("old")))
+
+	source := func(add func(item string)) error {
+		for _, item := range items {
+			add(item)
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i, item := range items {
+				switch {
+				case w == 0 && i%250 == 0:
+					filter.Clear()
+				case w == 1 && i%250 == 0:
+					if err := filter.Rekey([]byte{byte(i)}, source); err != nil {
+						t.Errorf("Failed to rekey: %v", err)
+					}
+				case w%2 == 0:
+					filter.Add(item)
+				default:
+					filter.Exists(item)
+				}
+				filter.GetStatistics()
+				filter.FillRatio()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// a final rekey replays everything regardless of the interleaving
+	if err := filter.Rekey([]byte("new"), source); err != nil {
+		t.Fatalf("Failed to rekey: %v", err)
+	}
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist after rekeying", item)
+		}
+	}
+}
+
+func TestConcurrentAddIfAbsent(t *testing.T) {
+	workers := stressWorkers()
+	items := test.GenerateStringsOfLength(12, 2000)
+
+	for _, filterType := range FilterTypes {
+		params := CalculateOptimalParameters(len(items), 0.001)
+		params.Type = filterType
+		params.RotationItems = uint64(10 * len(items))
+		filter, err := NewFilter(params)
+		if err != nil {
+			t.Fatalf("Failed to create %s filter: %v", filterType, err)
+		}
+		adder, ok := filter.(ConditionalAdder)
+		if !ok {
+			continue
+		}
+
+		t.Run(string(filterType), func(t *testing.T) {
+			// every worker offers every item, only one may see it as new
+			winners := make([]atomic.Int32, len(items))
+			var wg sync.WaitGroup
+			for range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i, item := range items {
+						if !adder.AddIfAbsent(item) {
+							winners[i].Add(1)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			won := 0
+			for i := range winners {
+				switch n := winners[i].Load(); {
+				case n > 1:
+					t.Fatalf("Expected one producer to add item %s, got %d", items[i], n)
+				case n == 1:
+					won++
+				}
+			}
+			// an item can lose to a false positive, but only rarely
+			if won < len(items)*99/100 {
+				t.Fatalf("Expected nearly all %d items to be added once, got %d", len(items), won)
+			}
+		})
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultCounterWidth is the width in bits of the counters of a counting
+// filter when Parameters.CounterWidth is unset. 4-bit counters overflow with
+// negligible probability for optimally sized filters.
+const DefaultCounterWidth = 4
+
+// CountingBloomFilter is a bloom filter that keeps a small saturating counter
+// instead of a bit per slot, so items can be removed again. A counter that
+// reached its maximum stays saturated forever, since its true value is no
+// longer known; removing an item that is not in the filter is refused rather
+// than letting counters underflow.
+type CountingBloomFilter struct {
+	// parameters for the counting filter
+	params Parameters
+
+	// statistics for the counting filter
+	stats Statistics
+
+	// packed counters, 64/width per word
+	counters []uint64
+
+	// width in bits of a counter
+	width uint64
+
+	// number of non-zero counters
+	setBits uint64
+
+	// largest value a counter can hold
+	max uint64
+
+	// hasher the counter indices are derived from
+	hasher Hasher
+
+	// mutex for concurrent access
+	mu *sync.RWMutex
+}
+
+// NewCounting Creates a new CountingBloomFilter with params.Size counters of
+// params.CounterWidth bits
+// parameters:
+//
+//	params	: parameters of the counting filter
+//	opts	: optional behaviour, e.g. WithKey
+//
+// returns:
+//
+//	*CountingBloomFilter	: pointer to the CountingBloomFilter struct
+//	error					: error if the counter width is not supported
+func NewCounting(params Parameters, opts ...Option) (*CountingBloomFilter, error) {
+	if params.CounterWidth == 0 {
+		params.CounterWidth = DefaultCounterWidth
+	}
+	if err := checkCounterWidth(params.CounterWidth); err != nil {
+		return nil, err
+	}
+
+	hasher, err := newFilterHasher(params, buildOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if params.HashAlgorithm == "" {
+		params.HashAlgorithm = HashMurmur3
+	}
+	params.Type = TypeCounting
+
+	width := uint64(params.CounterWidth)
+	perWord := wordBits / width
+	return &CountingBloomFilter{
+		params:   params,
+		counters: make([]uint64, (params.Size+perWord-1)/perWord),
+		width:    width,
+		max:      1<<width - 1,
+		hasher:   hasher,
+		mu:       &sync.RWMutex{},
+	}, nil
+}
+
+// checkCounterWidth Checks that counters of width bits pack into a word
+func checkCounterWidth(width uint8) error {
+	switch width {
+	case 2, 4, 8, 16:
+		return nil
+	default:
+		return fmt.Errorf("bloom: unsupported counter width %d, must be 2, 4, 8 or 16", width)
+	}
+}
+
+// Add Adds an item to the counting filter
+// parameters:
+//
+//	item	: item to add to the counting filter
+//
+// returns:
+//
+//	none
+func (c *CountingBloomFilter) Add(item string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.add(c.doHash(item))
+}
+
+// AddMany Adds a batch of items to the counting filter under a single lock
+// parameters:
+//
+//	items	: items to add to the counting filter
+//
+// returns:
+//
+//	none
+func (c *CountingBloomFilter) AddMany(items []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, item := range items {
+		c.add(c.doHash(item))
+	}
+}
+
+// AddIfAbsent Adds an item to the counting filter unless it is already
+// present, in which case its counters are left untouched
+// parameters:
+//
+//	item	: item to add to the counting filter
+//
+// returns:
+//
+//	bool	: true if the item was already in the counting filter, false otherwise
+func (c *CountingBloomFilter) AddIfAbsent(item string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.CheckedItems++
+	h1, h2 := c.doHash(item)
+	if c.count(h1, h2) > 0 {
+		return true
+	}
+
+	c.add(h1, h2)
+	return false
+}
+
+// Remove Removes an item from the counting filter
+// parameters:
+//
+//	item	: item to remove from the counting filter
+//
+// returns:
+//
+//	error	: ErrNotPresent if the item is not in the filter
+func (c *CountingBloomFilter) Remove(item string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h1, h2 := c.doHash(item)
+	if c.count(h1, h2) == 0 {
+		return ErrNotPresent
+	}
+
+	c.stats.RemovedItems++
+	for i := range uint64(c.params.NumHashFunctions) {
+		idx := location(h1, h2, i, c.params.Size)
+		// saturated counters have lost their true value and must stay set
+		if v := c.get(idx); v > 0 && v < c.max {
+			c.put(idx, v-1)
+			if v == 1 {
+				c.setBits--
+			}
+		}
+	}
+	return nil
+}
+
+// Exists Checks if an item is in the counting filter
+// parameters:
+//
+//	item	: item to check in the counting filter
+//
+// returns:
+//
+//	bool	: true if the item is in the counting filter, false otherwise
+func (c *CountingBloomFilter) Exists(item string) bool {
+	return c.Count(item) > 0
+}
+
+// AddBytes Adds a binary item to the counting filter, see BytesFilter
+// parameters:
+//
+//	item	: item to add to the counting filter
+//
+// returns:
+//
+//	none
+func (c *CountingBloomFilter) AddBytes(item []byte) {
+	c.Add(bytesString(item))
+}
+
+// ExistsBytes Checks if a binary item is in the counting filter, see BytesFilter
+// parameters:
+//
+//	item	: item to check in the counting filter
+//
+// returns:
+//
+//	bool	: true if the item is in the counting filter, false otherwise
+func (c *CountingBloomFilter) ExistsBytes(item []byte) bool {
+	return c.Exists(bytesString(item))
+}
+
+// Count Estimates how many times an item was added, the smallest of its
+// counters; 0 means the item is not in the filter
+// parameters:
+//
+//	item	: item to count in the counting filter
+//
+// returns:
+//
+//	uint64	: upper bound of the number of times the item was added
+func (c *CountingBloomFilter) Count(item string) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	atomic.AddUint64(&c.stats.CheckedItems, 1)
+	h1, h2 := c.doHash(item)
+	return c.count(h1, h2)
+}
+
+// ExistsMany Checks a batch of items in the counting filter under a single lock
+// parameters:
+//
+//	items	: items to check in the counting filter
+//
+// returns:
+//
+//	[]bool	: for every item, true if it is in the counting filter, in the order of items
+func (c *CountingBloomFilter) ExistsMany(items []string) []bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	atomic.AddUint64(&c.stats.CheckedItems, uint64(len(items)))
+	exists := make([]bool, len(items))
+	for i, item := range items {
+		exists[i] = c.count(c.doHash(item)) > 0
+	}
+	return exists
+}
+
+// Clear Clears the counting filter
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	none
+func (c *CountingBloomFilter) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.counters)
+	c.setBits = 0
+}
+
+func (c *CountingBloomFilter) GetParameters() Parameters {
+	return c.params
+}
+
+func (c *CountingBloomFilter) GetStatistics() Statistics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := Statistics{
+		AddedItems:   c.stats.AddedItems,
+		CheckedItems: atomic.LoadUint64(&c.stats.CheckedItems),
+		RemovedItems: c.stats.RemovedItems,
+	}
+	// a non-zero counter is a set bit
+	stats.estimate(c.params.Size, uint64(c.params.NumHashFunctions), c.setBits)
+	return stats
+}
+
+// SizeInBytes Returns the memory held by the counters of the counting filter
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	uint64	: size of the counting filter in bytes
+func (c *CountingBloomFilter) SizeInBytes() uint64 {
+	return uint64(len(c.counters)) * 8
+}
+
+func (c *CountingBloomFilter) String() string {
+	return fmt.Sprintf("CountingBloomFilter{size: %d, hashFunctions: %d, counterWidth: %d}", c.params.Size, c.params.NumHashFunctions, c.width)
+}
+
+// doHash Hashes the input string once, see BloomFilter.doHash
+func (c *CountingBloomFilter) doHash(input string) (uint64, uint64) {
+	return c.hasher.Sum128(stringBytes(input))
+}
+
+// add Increments the counters of a hashed item
+func (c *CountingBloomFilter) add(h1, h2 uint64) {
+	c.stats.AddedItems++
+	for i := range uint64(c.params.NumHashFunctions) {
+		idx := location(h1, h2, i, c.params.Size)
+		if v := c.get(idx); v < c.max {
+			c.put(idx, v+1)
+			if v == 0 {
+				c.setBits++
+			}
+		}
+	}
+}
+
+// count Returns the smallest counter of a hashed item
+func (c *CountingBloomFilter) count(h1, h2 uint64) uint64 {
+	smallest := c.max
+	for i := range uint64(c.params.NumHashFunctions) {
+		if v := c.get(location(h1, h2, i, c.params.Size)); v < smallest {
+			smallest = v
+		}
+	}
+	return smallest
+}
+
+// get Returns the value of the counter at idx
+func (c *CountingBloomFilter) get(idx uint64) uint64 {
+	bit := idx * c.width
+	return (c.counters[bit/wordBits] >> (bit % wordBits)) & c.max
+}
+
+// put Stores v in the counter at idx
+func (c *CountingBloomFilter) put(idx, v uint64) {
+	bit := idx * c.width
+	shift := bit % wordBits
+	word := &c.counters[bit/wordBits]
+	*word = *word&^(c.max<<shift) | v<<shift
+}
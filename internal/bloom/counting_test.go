@@ -0,0 +1,120 @@
+package bloom
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func newCountingFilter(t *testing.T, n int, width uint8) *CountingBloomFilter {
+	t.Helper()
+	params := CalculateOptimalParameters(n, 0.01)
+	params.CounterWidth = width
+	filter, err := NewCounting(params)
+	if err != nil {
+		t.Fatalf("Failed to create counting filter: %v", err)
+	}
+	return filter
+}
+
+func TestCountingAddRemove(t *testing.T) {
+	items := test.GenerateStringsOfLength(10, 100)
+	filter := newCountingFilter(t, len(items), 0)
+
+	if got := filter.GetParameters().CounterWidth; got != DefaultCounterWidth {
+		t.Fatalf("Expected default counter width %d, got %d", DefaultCounterWidth, got)
+	}
+
+	for _, item := range items {
+		filter.Add(item)
+	}
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+
+	for _, item := range items[:50] {
+		if err := filter.Remove(item); err != nil {
+			t.Fatalf("Failed to remove item %s: %v", item, err)
+		}
+	}
+	for _, item := range items[50:] {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to survive removing other items", item)
+		}
+	}
+
+	stats := filter.GetStatistics()
+	if stats.AddedItems != 100 || stats.RemovedItems != 50 {
+		t.Fatalf("Expected 100 added and 50 removed items, got %+v", stats)
+	}
+}
+
+func TestCountingCount(t *testing.T) {
+	filter := newCountingFilter(t, 100, 4)
+
+	for range 3 {
+		filter.Add("apple")
+	}
+	if got := filter.Count("apple"); got != 3 {
+		t.Fatalf("Expected count 3, got %d", got)
+	}
+
+	if err := filter.Remove("apple"); err != nil {
+		t.Fatalf("Failed to remove item: %v", err)
+	}
+	if got := filter.Count("apple"); got != 2 {
+		t.Fatalf("Expected count 2, got %d", got)
+	}
+}
+
+func TestCountingUnderflow(t *testing.T) {
+	filter := newCountingFilter(t, 100, 4)
+	filter.Add("apple")
+
+	if err := filter.Remove("banana"); !errors.Is(err, ErrNotPresent) {
+		t.Fatalf("Expected ErrNotPresent, got %v", err)
+	}
+	if err := filter.Remove("apple"); err != nil {
+		t.Fatalf("Failed to remove item: %v", err)
+	}
+	if err := filter.Remove("apple"); !errors.Is(err, ErrNotPresent) {
+		t.Fatalf("Expected ErrNotPresent on second removal, got %v", err)
+	}
+	for i, word := range filter.counters {
+		if word != 0 {
+			t.Fatalf("Expected all counters to be zero, word %d is %x", i, word)
+		}
+	}
+}
+
+func TestCountingSaturation(t *testing.T) {
+	filter := newCountingFilter(t, 100, 2)
+
+	for range 10 {
+		filter.Add("apple")
+	}
+	if got := filter.Count("apple"); got != 3 {
+		t.Fatalf("Expected count to saturate at 3, got %d", got)
+	}
+
+	// saturated counters keep the item in the filter for good
+	for range 10 {
+		if err := filter.Remove("apple"); err != nil {
+			t.Fatalf("Failed to remove saturated item: %v", err)
+		}
+	}
+	if !filter.Exists("apple") {
+		t.Fatalf("Expected saturated item to stay in the filter")
+	}
+}
+
+func TestCountingInvalidWidth(t *testing.T) {
+	params := CalculateOptimalParameters(100, 0.01)
+	params.CounterWidth = 3
+	if _, err := NewCounting(params); err == nil {
+		t.Fatalf("Expected an error for a 3-bit counter")
+	}
+}
@@ -0,0 +1,452 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultBucketSize is the number of fingerprints per bucket of a cuckoo
+	// filter when Parameters.BucketSize is unset
+	DefaultBucketSize = 4
+	// DefaultMaxKicks is the number of relocations a cuckoo filter attempts
+	// before an insert fails when Parameters.MaxKicks is unset
+	DefaultMaxKicks = 500
+	// cuckooLoadFactor is the occupancy a cuckoo filter with 4-slot buckets
+	// reliably reaches, used to size the table from the capacity
+	cuckooLoadFactor = 0.95
+)
+
+// ErrFilterFull is returned when an item cannot be inserted into a full filter
+var ErrFilterFull = errors.New("bloom: filter is full")
+
+// CuckooFilter is a probabilistic set storing a short fingerprint of every
+// item in one of two candidate buckets (Fan et al., "Cuckoo Filter:
+// Practically Better Than Bloom"). It supports Remove and needs less space
+// than a bloom filter at low false positive rates. When both buckets of an
+// item are full, resident fingerprints are relocated to their alternate
+// bucket up to MaxKicks times; if that fails the last evicted fingerprint is
+// kept aside and the filter reports ErrFilterFull for further inserts.
+type CuckooFilter struct {
+	// parameters for the cuckoo filter
+	params Parameters
+
+	// statistics for the cuckoo filter
+	stats Statistics
+
+	// packed fingerprint slots, bucket after bucket
+	slots []uint64
+
+	// number of buckets, a power of two
+	numBuckets uint64
+
+	// number of slots per bucket
+	bucketSize uint64
+
+	// width in bits of a fingerprint
+	fpBits uint64
+
+	// fingerprint evicted by a failed relocation, 0 if unused
+	victim      uint64
+	victimIndex uint64
+
+	// number of stored fingerprints, including the victim
+	count uint64
+
+	// state of the generator picking which fingerprint to relocate
+	rng uint64
+
+	// hasher the bucket and fingerprint are derived from
+	hasher Hasher
+
+	// mutex for concurrent access
+	mu *sync.RWMutex
+}
+
+// NewCuckoo Creates a new CuckooFilter sized to hold params.Capacity items,
+// or params.Size bits of fingerprints if the capacity is unset. The
+// fingerprint width defaults to the smallest that meets
+// params.FalsePositiveRate.
+// parameters:
+//
+//	params	: parameters of the cuckoo filter
+//	opts	: optional behaviour, e.g. WithKey
+//
+// returns:
+//
+//	*CuckooFilter	: pointer to the CuckooFilter struct
+//	error			: error if the parameters are invalid
+func NewCuckoo(params Parameters, opts ...Option) (*CuckooFilter, error) {
+	params, numBuckets, err := cuckooLayout(params)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := newFilterHasher(params, buildOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if params.HashAlgorithm == "" {
+		params.HashAlgorithm = HashMurmur3
+	}
+
+	return &CuckooFilter{
+		params:     params,
+		slots:      make([]uint64, (params.Size+wordBits-1)/wordBits),
+		numBuckets: numBuckets,
+		bucketSize: uint64(params.BucketSize),
+		fpBits:     uint64(params.FingerprintBits),
+		rng:        mix64(params.Seed) | 1,
+		hasher:     hasher,
+		mu:         &sync.RWMutex{},
+	}, nil
+}
+
+// cuckooLayout Fills in the defaults of the parameters of a cuckoo filter
+// and derives its size and number of buckets
+func cuckooLayout(params Parameters) (Parameters, uint64, error) {
+	if params.BucketSize == 0 {
+		params.BucketSize = DefaultBucketSize
+	}
+	if params.MaxKicks == 0 {
+		params.MaxKicks = DefaultMaxKicks
+	}
+	if params.FingerprintBits == 0 {
+		if params.FalsePositiveRate <= 0 || params.FalsePositiveRate >= 1 {
+			return params, 0, fmt.Errorf("bloom: false positive rate %v must be between 0 and 1", params.FalsePositiveRate)
+		}
+		// a lookup compares 2*b fingerprints, each matching with 2^-f
+		f := math.Ceil(math.Log2(2 * float64(params.BucketSize) / params.FalsePositiveRate))
+		params.FingerprintBits = uint8(max(f, 4))
+	}
+	if params.FingerprintBits < 4 || params.FingerprintBits > 32 {
+		return params, 0, fmt.Errorf("bloom: fingerprint size %d must be between 4 and 32 bits", params.FingerprintBits)
+	}
+
+	bucketSize, fpBits := uint64(params.BucketSize), uint64(params.FingerprintBits)
+	var buckets uint64
+	if params.Capacity > 0 {
+		buckets = uint64(math.Ceil(float64(params.Capacity) / float64(bucketSize) / cuckooLoadFactor))
+	} else {
+		buckets = params.Size / (bucketSize * fpBits)
+	}
+	if buckets == 0 {
+		return params, 0, errors.New("bloom: cuckoo filter needs a capacity or size")
+	}
+	// the alternate bucket is derived by xor, which needs a power of two
+	numBuckets := uint64(1) << bits.Len64(buckets-1)
+
+	params.Type = TypeCuckoo
+	params.Size = numBuckets * bucketSize * fpBits
+	params.NumHashFunctions = 2
+	params.FalsePositiveRate = 2 * float64(bucketSize) / math.Exp2(float64(fpBits))
+	return params, numBuckets, nil
+}
+
+// Add Adds an item to the cuckoo filter, counting it in
+// Statistics.FailedInserts if the filter is full
+// parameters:
+//
+//	item	: item to add to the cuckoo filter
+//
+// returns:
+//
+//	none
+func (c *CuckooFilter) Add(item string) {
+	_ = c.Insert(item)
+}
+
+// Insert Adds an item to the cuckoo filter
+// parameters:
+//
+//	item	: item to add to the cuckoo filter
+//
+// returns:
+//
+//	error	: ErrFilterFull if the item could not be stored
+func (c *CuckooFilter) Insert(item string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.victim != 0 {
+		c.stats.FailedInserts++
+		return ErrFilterFull
+	}
+
+	c.stats.AddedItems++
+	fp, i1 := c.doHash(item)
+	c.count++
+	if c.insertAt(i1, fp) || c.insertAt(c.altIndex(i1, fp), fp) {
+		return nil
+	}
+
+	// relocate resident fingerprints to their alternate bucket
+	idx := i1
+	if c.next()&1 == 1 {
+		idx = c.altIndex(i1, fp)
+	}
+	for range c.params.MaxKicks {
+		slot := c.next() % c.bucketSize
+		evicted := c.get(idx, slot)
+		c.put(idx, slot, fp)
+		fp = evicted
+
+		idx = c.altIndex(idx, fp)
+		if c.insertAt(idx, fp) {
+			return nil
+		}
+	}
+
+	// keep the last evicted fingerprint so no stored item is lost
+	c.victim, c.victimIndex = fp, idx
+	return nil
+}
+
+// Exists Checks if an item is in the cuckoo filter
+// parameters:
+//
+//	item	: item to check in the cuckoo filter
+//
+// returns:
+//
+//	bool	: true if the item is in the cuckoo filter, false otherwise
+func (c *CuckooFilter) Exists(item string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	atomic.AddUint64(&c.stats.CheckedItems, 1)
+	fp, i1 := c.doHash(item)
+	i2 := c.altIndex(i1, fp)
+	if c.victim == fp && (c.victimIndex == i1 || c.victimIndex == i2) {
+		return true
+	}
+	return c.find(i1, fp) >= 0 || c.find(i2, fp) >= 0
+}
+
+// AddBytes Adds a binary item to the cuckoo filter, see BytesFilter
+// parameters:
+//
+//	item	: item to add to the cuckoo filter
+//
+// returns:
+//
+//	none
+func (c *CuckooFilter) AddBytes(item []byte) {
+	c.Add(bytesString(item))
+}
+
+// ExistsBytes Checks if a binary item is in the cuckoo filter, see BytesFilter
+// parameters:
+//
+//	item	: item to check in the cuckoo filter
+//
+// returns:
+//
+//	bool	: true if the item is in the cuckoo filter, false otherwise
+func (c *CuckooFilter) ExistsBytes(item []byte) bool {
+	return c.Exists(bytesString(item))
+}
+
+// Remove Removes an item from the cuckoo filter. Removing an item that was
+// never added may remove another item sharing its fingerprint.
+// parameters:
+//
+//	item	: item to remove from the cuckoo filter
+//
+// returns:
+//
+//	error	: ErrNotPresent if the item is not in the filter
+func (c *CuckooFilter) Remove(item string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fp, i1 := c.doHash(item)
+	i2 := c.altIndex(i1, fp)
+	switch {
+	case c.victim == fp && (c.victimIndex == i1 || c.victimIndex == i2):
+		c.victim = 0
+	case c.removeAt(i1, fp), c.removeAt(i2, fp):
+		// a slot was freed, make room for the victim
+		if c.victim != 0 {
+			victim, idx := c.victim, c.victimIndex
+			c.victim = 0
+			if !c.insertAt(idx, victim) && !c.insertAt(c.altIndex(idx, victim), victim) {
+				c.victim = victim
+			}
+		}
+	default:
+		return ErrNotPresent
+	}
+
+	c.count--
+	c.stats.RemovedItems++
+	return nil
+}
+
+// Clear Clears the cuckoo filter
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	none
+func (c *CuckooFilter) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.slots)
+	c.victim, c.victimIndex, c.count = 0, 0, 0
+}
+
+// LoadFactor Returns the fraction of slots holding a fingerprint
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	float64	: stored fingerprints divided by the number of slots
+func (c *CuckooFilter) LoadFactor() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return float64(c.count) / float64(c.numBuckets*c.bucketSize)
+}
+
+func (c *CuckooFilter) GetParameters() Parameters {
+	return c.params
+}
+
+func (c *CuckooFilter) GetStatistics() Statistics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// a lookup compares against every stored fingerprint of two buckets, each
+	// matching with probability 1/(2^f-1)
+	load := float64(c.count) / float64(c.numBuckets*c.bucketSize)
+	compared := 2 * float64(c.bucketSize) * load
+	return Statistics{
+		AddedItems:                 c.stats.AddedItems,
+		CheckedItems:               atomic.LoadUint64(&c.stats.CheckedItems),
+		RemovedItems:               c.stats.RemovedItems,
+		FailedInserts:              c.stats.FailedInserts,
+		EstimatedItems:             float64(c.count),
+		EstimatedFalsePositiveRate: 1 - math.Pow(1-1/(math.Exp2(float64(c.fpBits))-1), compared),
+	}
+}
+
+// SizeInBytes Returns the memory held by the fingerprint slots of the cuckoo filter
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	uint64	: size of the cuckoo filter in bytes
+func (c *CuckooFilter) SizeInBytes() uint64 {
+	return uint64(len(c.slots)) * 8
+}
+
+func (c *CuckooFilter) String() string {
+	return fmt.Sprintf("CuckooFilter{buckets: %d, bucketSize: %d, fingerprintBits: %d}", c.numBuckets, c.bucketSize, c.fpBits)
+}
+
+// doHash Hashes the input string once into its fingerprint, never 0 since
+// 0 marks an empty slot, and its primary bucket
+func (c *CuckooFilter) doHash(input string) (uint64, uint64) {
+	h1, h2 := c.hasher.Sum128(stringBytes(input))
+	fp := h2 & (1<<c.fpBits - 1)
+	if fp == 0 {
+		fp = 1
+	}
+	return fp, h1 & (c.numBuckets - 1)
+}
+
+// altIndex Returns the other candidate bucket of a fingerprint stored in
+// bucket idx; altIndex(altIndex(i, fp), fp) == i
+func (c *CuckooFilter) altIndex(idx, fp uint64) uint64 {
+	return (idx ^ mix64(fp)) & (c.numBuckets - 1)
+}
+
+// next Returns the next value of the xorshift generator
+func (c *CuckooFilter) next() uint64 {
+	c.rng ^= c.rng << 13
+	c.rng ^= c.rng >> 7
+	c.rng ^= c.rng << 17
+	return c.rng
+}
+
+// insertAt Stores fp in a free slot of bucket idx
+func (c *CuckooFilter) insertAt(idx, fp uint64) bool {
+	for slot := range c.bucketSize {
+		if c.get(idx, slot) == 0 {
+			c.put(idx, slot, fp)
+			return true
+		}
+	}
+	return false
+}
+
+// removeAt Frees the slot of bucket idx holding fp
+func (c *CuckooFilter) removeAt(idx, fp uint64) bool {
+	if slot := c.find(idx, fp); slot >= 0 {
+		c.put(idx, uint64(slot), 0)
+		return true
+	}
+	return false
+}
+
+// find Returns the slot of bucket idx holding fp, or -1
+func (c *CuckooFilter) find(idx, fp uint64) int {
+	for slot := range c.bucketSize {
+		if c.get(idx, slot) == fp {
+			return int(slot)
+		}
+	}
+	return -1
+}
+
+// get Returns the fingerprint in a slot, fingerprints may straddle words
+func (c *CuckooFilter) get(idx, slot uint64) uint64 {
+	bit := (idx*c.bucketSize + slot) * c.fpBits
+	word, shift := bit/wordBits, bit%wordBits
+	mask := uint64(1)<<c.fpBits - 1
+
+	v := c.slots[word] >> shift
+	if shift+c.fpBits > wordBits {
+		v |= c.slots[word+1] << (wordBits - shift)
+	}
+	return v & mask
+}
+
+// put Stores a fingerprint in a slot
+func (c *CuckooFilter) put(idx, slot, fp uint64) {
+	bit := (idx*c.bucketSize + slot) * c.fpBits
+	word, shift := bit/wordBits, bit%wordBits
+	mask := uint64(1)<<c.fpBits - 1
+
+	c.slots[word] = c.slots[word]&^(mask<<shift) | fp<<shift
+	if shift+c.fpBits > wordBits {
+		rest := wordBits - shift
+		c.slots[word+1] = c.slots[word+1]&^(mask>>rest) | fp>>rest
+	}
+}
@@ -0,0 +1,151 @@
+package bloom
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func newCuckooFilter(t *testing.T, params Parameters) *CuckooFilter {
+	t.Helper()
+	filter, err := NewCuckoo(params)
+	if err != nil {
+		t.Fatalf("Failed to create cuckoo filter: %v", err)
+	}
+	return filter
+}
+
+func TestCuckooAddExistsRemove(t *testing.T) {
+	items := test.GenerateStringsOfLength(10, 1000)
+	filter := newCuckooFilter(t, CalculateOptimalParameters(len(items), 0.01))
+
+	for _, item := range items {
+		if err := filter.Insert(item); err != nil {
+			t.Fatalf("Failed to insert item %s: %v", item, err)
+		}
+	}
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+
+	for _, item := range items[:500] {
+		if err := filter.Remove(item); err != nil {
+			t.Fatalf("Failed to remove item %s: %v", item, err)
+		}
+	}
+	for _, item := range items[500:] {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to survive removing other items", item)
+		}
+	}
+	if err := filter.Remove("never-added"); !errors.Is(err, ErrNotPresent) {
+		t.Fatalf("Expected ErrNotPresent, got %v", err)
+	}
+}
+
+func TestCuckooFingerprintSize(t *testing.T) {
+	params := CalculateOptimalParameters(1000, 0.01)
+	filter := newCuckooFilter(t, params)
+
+	// 2*4/2^f <= 0.01 needs f = 10
+	got := filter.GetParameters()
+	if got.FingerprintBits != 10 || got.BucketSize != DefaultBucketSize {
+		t.Fatalf("Expected 10-bit fingerprints in buckets of %d, got %+v", DefaultBucketSize, got)
+	}
+	if got.FalsePositiveRate > 0.01 {
+		t.Fatalf("Expected false positive rate below 0.01, got %v", got.FalsePositiveRate)
+	}
+
+	for _, bits := range []uint8{7, 13, 32} {
+		params.FingerprintBits = bits
+		filter := newCuckooFilter(t, params)
+		items := test.GenerateStringsOfLength(10, 500)
+		for _, item := range items {
+			filter.Add(item)
+		}
+		for _, item := range items {
+			if !filter.Exists(item) {
+				t.Fatalf("%d-bit fingerprints: expected item %s to exist", bits, item)
+			}
+		}
+	}
+
+	params.FingerprintBits = 40
+	if _, err := NewCuckoo(params); err == nil {
+		t.Fatalf("Expected an error for 40-bit fingerprints")
+	}
+}
+
+func TestCuckooFalsePositiveRate(t *testing.T) {
+	n := 10_000
+	filter := newCuckooFilter(t, CalculateOptimalParameters(n, 0.01))
+	for _, item := range test.GenerateStringsOfLength(12, n) {
+		filter.Add(item)
+	}
+
+	testN := 50_000
+	falsePositives := 0
+	for _, item := range test.GenerateStringsOfLength(10, testN) {
+		if filter.Exists(item) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / float64(testN); rate > 0.015 {
+		t.Fatalf("Expected false positive rate below 0.01, got %v", rate)
+	}
+}
+
+func TestCuckooFull(t *testing.T) {
+	params := CalculateOptimalParameters(64, 0.01)
+	params.MaxKicks = 50
+	filter := newCuckooFilter(t, params)
+
+	var failed error
+	stored := []string{}
+	for _, item := range test.GenerateStringsOfLength(10, 1000) {
+		if failed = filter.Insert(item); failed != nil {
+			break
+		}
+		stored = append(stored, item)
+	}
+	if !errors.Is(failed, ErrFilterFull) {
+		t.Fatalf("Expected ErrFilterFull, got %v", failed)
+	}
+	if got := filter.GetStatistics().FailedInserts; got != 1 {
+		t.Fatalf("Expected 1 failed insert, got %d", got)
+	}
+
+	// no stored item may be lost when the filter fills up
+	for _, item := range stored {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected stored item %s to exist", item)
+		}
+	}
+
+	// removing items frees a slot for the kept aside fingerprint, which
+	// makes room for new inserts again
+	for _, item := range stored {
+		if err := filter.Remove(item); err != nil {
+			t.Fatalf("Failed to remove item %s: %v", item, err)
+		}
+		if filter.victim == 0 {
+			break
+		}
+	}
+	if err := filter.Insert("fresh"); err != nil {
+		t.Fatalf("Expected room after removal, got %v", err)
+	}
+}
+
+func TestCuckooClear(t *testing.T) {
+	filter := newCuckooFilter(t, CalculateOptimalParameters(100, 0.01))
+	filter.Add("apple")
+	filter.Clear()
+
+	if filter.Exists("apple") || filter.LoadFactor() != 0 {
+		t.Fatalf("Expected an empty filter after Clear")
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FilterType names the kind of probabilistic set a filter is
+type FilterType string
+
+const (
+	// TypeStandard is the classic BloomFilter, the default
+	TypeStandard FilterType = "standard"
+	// TypeCounting is a CountingBloomFilter that supports Remove
+	TypeCounting FilterType = "counting"
+	// TypeScalable is a ScalableBloomFilter that grows past its capacity
+	TypeScalable FilterType = "scalable"
+	// TypeRotating is a RotatingBloomFilter whose items expire
+	TypeRotating FilterType = "rotating"
+	// TypeCuckoo is a CuckooFilter that supports Remove
+	TypeCuckoo FilterType = "cuckoo"
+	// TypeBlocked is a BlockedBloomFilter with cache-line sized blocks
+	TypeBlocked FilterType = "blocked"
+	// TypeSharded is a ShardedBloomFilter with a lock per shard
+	TypeSharded FilterType = "sharded"
+)
+
+// FilterTypes lists the supported filter types
+var FilterTypes = []FilterType{TypeStandard, TypeCounting, TypeScalable, TypeRotating, TypeCuckoo, TypeBlocked, TypeSharded}
+
+// ErrNotPresent is returned when removing an item that is not in the filter
+var ErrNotPresent = errors.New("bloom: item is not in the filter")
+
+// ProbabilisticFilter is the interface implemented by every filter type of
+// the package
+type ProbabilisticFilter interface {
+	// Add adds an item to the filter
+	Add(item string)
+	// Exists reports whether the item might be in the filter
+	Exists(item string) bool
+	// Clear resets the filter to its initial, empty state
+	Clear()
+	// GetParameters returns the parameters the filter was created with
+	GetParameters() Parameters
+	// GetStatistics returns the runtime statistics of the filter
+	GetStatistics() Statistics
+	// SizeInBytes returns the memory held by the bits, counters or
+	// fingerprints of the filter and its per-filter locks
+	SizeInBytes() uint64
+}
+
+// BytesFilter is implemented by filters that take binary items without
+// copying them. A binary item maps to the same bits as the string holding the
+// same bytes, so Add and AddBytes, Exists and ExistsBytes may be mixed freely.
+type BytesFilter interface {
+	// AddBytes adds a binary item to the filter
+	AddBytes(item []byte)
+	// ExistsBytes reports whether the binary item might be in the filter
+	ExistsBytes(item []byte) bool
+}
+
+// Inserter is implemented by filters whose inserts can fail
+type Inserter interface {
+	// Insert adds an item, or returns ErrFilterFull if there is no room
+	Insert(item string) error
+}
+
+// BatchInserter is implemented by filters whose batched inserts can fail
+type BatchInserter interface {
+	// InsertMany adds the items of the batch in order, up to the first one
+	// there is no room for, and returns the number of items added
+	InsertMany(items []string) (int, error)
+}
+
+// ConditionalAdder is implemented by filters that can test for an item and
+// add it in one step, so concurrent producers of the same item cannot both
+// see it as new
+type ConditionalAdder interface {
+	// AddIfAbsent adds an item unless it might already be in the filter and
+	// reports whether it was
+	AddIfAbsent(item string) (wasPresent bool)
+}
+
+// Batcher is implemented by filters that add and check a batch of items at
+// once, taking their locks once per batch instead of once per item
+type Batcher interface {
+	// AddMany adds every item of the batch
+	AddMany(items []string)
+	// ExistsMany reports for every item of the batch whether it might be in
+	// the filter, in the order of items
+	ExistsMany(items []string) []bool
+}
+
+// Remover is implemented by filters that support removing items
+type Remover interface {
+	// Remove removes an item, or returns ErrNotPresent if it is not in the
+	// filter
+	Remove(item string) error
+}
+
+// ParseFilterType Validates the name of a filter type
+// parameters:
+//
+//	name	: name of the filter type, empty selects the default
+//
+// returns:
+//
+//	FilterType	: the matching filter type
+//	error		: error if the filter type is unknown
+func ParseFilterType(name string) (FilterType, error) {
+	if name == "" {
+		return TypeStandard, nil
+	}
+	for _, filterType := range FilterTypes {
+		if string(filterType) == name {
+			return filterType, nil
+		}
+	}
+	return "", fmt.Errorf("bloom: unknown filter type %q", name)
+}
+
+// NewFilter Creates a filter of the type selected by params.Type
+// parameters:
+//
+//	params	: parameters of the filter
+//	opts	: optional behaviour, e.g. WithKey
+//
+// returns:
+//
+//	ProbabilisticFilter	: the new filter
+//	error				: error if the parameters are invalid
+func NewFilter(params Parameters, opts ...Option) (ProbabilisticFilter, error) {
+	if _, err := ParseHashAlgorithm(string(params.HashAlgorithm)); err != nil {
+		return nil, err
+	}
+	if params.Type == TypeCuckoo {
+		return NewCuckoo(params, opts...)
+	}
+
+	if params.Size == 0 {
+		return nil, errors.New("bloom: size must be greater than 0")
+	}
+	if params.NumHashFunctions == 0 {
+		return nil, errors.New("bloom: number of hash functions must be greater than 0")
+	}
+
+	switch params.Type {
+	case TypeStandard, "":
+		params.Type = TypeStandard
+		return New(params, opts...), nil
+	case TypeCounting:
+		return NewCounting(params, opts...)
+	case TypeScalable:
+		return NewScalable(params, opts...)
+	case TypeRotating:
+		return NewRotating(params, opts...)
+	case TypeBlocked:
+		return NewBlocked(params, opts...)
+	case TypeSharded:
+		return NewSharded(params, opts...)
+	default:
+		return nil, fmt.Errorf("bloom: unknown filter type %q", params.Type)
+	}
+}
+
+// newFilterHasher Creates the hasher of a filter, keyed if opts carry a key
+func newFilterHasher(params Parameters, o options) (Hasher, error) {
+	hasher, err := NewHasher(params.HashAlgorithm, params.Seed)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyedHasher(hasher, o.key), nil
+}
+
+// location Returns the i-th index of a hashed item in a filter of size
+// slots, g_i = h1 + i*(2*h2+1) mod size. The step is forced odd, so it is
+// never 0 and h2 == 0 does not collapse the k probes of an item into a single
+// slot. With an odd size the step can still be a multiple of size, which
+// collapses them for about one item in size.
+func location(h1, h2, i, size uint64) uint64 {
+	return (h1 + i*(h2<<1|1)) % size
+}
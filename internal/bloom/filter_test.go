@@ -0,0 +1,208 @@
+package bloom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func TestNewFilter(t *testing.T) {
+	params := CalculateOptimalParameters(100, 0.01)
+	params.Type = TypeCounting
+	filter, err := NewFilter(params)
+	if err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+	if _, ok := filter.(Remover); !ok {
+		t.Fatalf("Expected a counting filter to support Remove")
+	}
+
+	params.Type = TypeStandard
+	if filter, _ = NewFilter(params); filter.GetParameters().Type != TypeStandard {
+		t.Fatalf("Expected a standard filter")
+	}
+
+	params.Type = "unknown"
+	if _, err := NewFilter(params); err == nil {
+		t.Fatalf("Expected an error for an unknown filter type")
+	}
+}
+
+func TestParseFilterType(t *testing.T) {
+	if filterType, err := ParseFilterType(""); err != nil || filterType != TypeStandard {
+		t.Fatalf("Expected default %s, got %s (%v)", TypeStandard, filterType, err)
+	}
+	if _, err := ParseFilterType("quotient"); err == nil {
+		t.Fatalf("Expected an error for an unknown filter type")
+	}
+}
+
+func TestLocationZeroStep(t *testing.T) {
+	// h2 == 0 must still probe k distinct slots
+	seen := make(map[uint64]bool)
+	for i := range uint64(7) {
+		seen[location(12345, 0, i, 1<<20)] = true
+	}
+	if len(seen) != 7 {
+		t.Fatalf("Expected 7 distinct slots for h2 == 0, got %d", len(seen))
+	}
+}
+
+func TestAddIfAbsent(t *testing.T) {
+	for _, filterType := range FilterTypes {
+		params := CalculateOptimalParameters(100, 0.01)
+		params.Type = filterType
+		params.RotationItems = 1000
+		filter, err := NewFilter(params)
+		if err != nil {
+			t.Fatalf("Failed to create %s filter: %v", filterType, err)
+		}
+		adder, ok := filter.(ConditionalAdder)
+		if !ok {
+			continue
+		}
+
+		if adder.AddIfAbsent("apple") {
+			t.Fatalf("Expected apple to be new to the %s filter", filterType)
+		}
+		if !adder.AddIfAbsent("apple") {
+			t.Fatalf("Expected apple to be present in the %s filter", filterType)
+		}
+		if !filter.Exists("apple") {
+			t.Fatalf("Expected apple to exist in the %s filter", filterType)
+		}
+	}
+
+	// a present item must not be counted twice
+	counting, _ := NewCounting(CalculateOptimalParameters(100, 0.01))
+	counting.AddIfAbsent("apple")
+	counting.AddIfAbsent("apple")
+	if n := counting.Count("apple"); n != 1 {
+		t.Fatalf("Expected apple to be counted once, got %d", n)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	items := test.GenerateStringsOfLength(10, 500)
+	missing := test.GenerateStringsOfLength(14, 500)
+
+	for _, filterType := range FilterTypes {
+		params := CalculateOptimalParameters(1000, 0.001)
+		params.Type = filterType
+		params.RotationItems = 10_000
+		filter, err := NewFilter(params)
+		if err != nil {
+			t.Fatalf("Failed to create %s filter: %v", filterType, err)
+		}
+		batcher, ok := filter.(Batcher)
+		if !ok {
+			continue
+		}
+
+		batcher.AddMany(items)
+		exists := batcher.ExistsMany(append(items, missing...))
+		if len(exists) != len(items)+len(missing) {
+			t.Fatalf("Expected %d results from the %s filter, got %d", len(items)+len(missing), filterType, len(exists))
+		}
+		for i, item := range items {
+			if !exists[i] || !filter.Exists(item) {
+				t.Fatalf("Expected item %s to exist in the %s filter", item, filterType)
+			}
+		}
+		falsePositives := 0
+		for _, found := range exists[len(items):] {
+			if found {
+				falsePositives++
+			}
+		}
+		if falsePositives > len(missing)/50 {
+			t.Fatalf("Expected missing items to be reported missing by the %s filter, %d were found", filterType, falsePositives)
+		}
+
+		stats := filter.GetStatistics()
+		if stats.AddedItems != uint64(len(items)) || stats.CheckedItems != uint64(2*len(items)+len(missing)) {
+			t.Fatalf("Expected every item of a batch to be counted by the %s filter, got %+v", filterType, stats)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	raw := []byte{0x00, 0xff, 0x10, 0x80, 0x00}
+
+	for _, filterType := range FilterTypes {
+		params := CalculateOptimalParameters(100, 0.01)
+		params.Type = filterType
+		params.RotationItems = 1000
+		filter, err := NewFilter(params)
+		if err != nil {
+			t.Fatalf("Failed to create %s filter: %v", filterType, err)
+		}
+
+		binary, ok := filter.(BytesFilter)
+		if !ok {
+			t.Fatalf("Expected the %s filter to take binary items", filterType)
+		}
+		binary.AddBytes(raw)
+		if !binary.ExistsBytes(raw) || !filter.Exists(string(raw)) {
+			t.Fatalf("Expected the binary item to exist in the %s filter", filterType)
+		}
+		if binary.ExistsBytes([]byte{0x00, 0xff}) {
+			t.Fatalf("Expected a prefix of the binary item to be missing from the %s filter", filterType)
+		}
+	}
+}
+
+func TestExistsBytesDoesNotAllocate(t *testing.T) {
+	for _, alg := range HashAlgorithms {
+		params := CalculateOptimalParameters(100, 0.01)
+		params.HashAlgorithm = alg
+		filter := New(params, WithKey([]byte("secret")))
+		item := []byte("0123456789abcdef")
+
+		if allocs := testing.AllocsPerRun(100, func() { filter.AddBytes(item); filter.ExistsBytes(item) }); allocs != 0 {
+			t.Fatalf("Expected %s to hash without allocating, got %f allocations", alg, allocs)
+		}
+	}
+}
+
+func TestEstimatesByType(t *testing.T) {
+	n := 2000
+	items := test.GenerateStringsOfLength(10, n)
+
+	for _, filterType := range FilterTypes {
+		t.Run(string(filterType), func(t *testing.T) {
+			params := CalculateOptimalParameters(n, 0.01)
+			params.Type = filterType
+			params.RotationItems = uint64(10 * n)
+			filter, err := NewFilter(params)
+			if err != nil {
+				t.Fatalf("Failed to create filter: %v", err)
+			}
+
+			for _, item := range items {
+				filter.Add(item)
+			}
+			stats := filter.GetStatistics()
+			if math.Abs(stats.EstimatedItems-float64(n)) > 0.05*float64(n) {
+				t.Fatalf("Expected about %d estimated items, got %f", n, stats.EstimatedItems)
+			}
+			if stats.EstimatedFalsePositiveRate <= 0 || stats.EstimatedFalsePositiveRate > 0.02 {
+				t.Fatalf("Expected an estimated false positive rate near 0.01, got %f", stats.EstimatedFalsePositiveRate)
+			}
+
+			remover, ok := filter.(Remover)
+			if !ok {
+				return
+			}
+			for _, item := range items {
+				if err := remover.Remove(item); err != nil {
+					t.Fatalf("Failed to remove item %s: %v", item, err)
+				}
+			}
+			if stats := filter.GetStatistics(); stats.EstimatedItems > 0.01*float64(n) {
+				t.Fatalf("Expected no estimated items after removing them all, got %f", stats.EstimatedItems)
+			}
+		})
+	}
+}
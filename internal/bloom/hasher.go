@@ -0,0 +1,169 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/dchest/siphash"
+	"github.com/spaolacci/murmur3"
+)
+
+// HashAlgorithm names the hash family a filter derives its bit indices from
+type HashAlgorithm string
+
+const (
+	// HashMurmur3 is murmur3 x64 128-bit, the default
+	HashMurmur3 HashAlgorithm = "murmur3_128"
+	// HashXXHash64 is xxHash64, the fastest of the built-in algorithms
+	HashXXHash64 HashAlgorithm = "xxhash64"
+	// HashFNV1a is 64-bit FNV-1a, for compatibility with systems using it
+	HashFNV1a HashAlgorithm = "fnv1a"
+	// HashSipHash is SipHash-2-4 128-bit keyed by the seed, for resistance
+	// against adversarially chosen keys
+	HashSipHash HashAlgorithm = "siphash"
+)
+
+// HashAlgorithms lists the built-in hash algorithms
+var HashAlgorithms = []HashAlgorithm{HashMurmur3, HashXXHash64, HashFNV1a, HashSipHash}
+
+// Hasher hashes an item into the two 64-bit values the bit indices of a
+// filter are derived from by double hashing. Implementations must be safe
+// for concurrent use, and must neither modify nor retain data, which may
+// alias the bytes of a string.
+type Hasher interface {
+	Sum128(data []byte) (uint64, uint64)
+}
+
+// stringBytes Returns the bytes of s without copying them. The result must
+// not be modified.
+func stringBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// bytesString Returns b as a string without copying it. The result is only
+// valid as long as b is not modified, so it must not outlive the call it is
+// passed to.
+func bytesString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// ParseHashAlgorithm Validates the name of a hash algorithm
+// parameters:
+//
+//	name	: name of the algorithm, empty selects the default
+//
+// returns:
+//
+//	HashAlgorithm	: the matching algorithm
+//	error			: error if the algorithm is unknown
+func ParseHashAlgorithm(name string) (HashAlgorithm, error) {
+	if name == "" {
+		return HashMurmur3, nil
+	}
+	for _, algorithm := range HashAlgorithms {
+		if string(algorithm) == name {
+			return algorithm, nil
+		}
+	}
+	return "", fmt.Errorf("bloom: unknown hash algorithm %q", name)
+}
+
+// NewHasher Creates the Hasher of an algorithm seeded with seed
+// parameters:
+//
+//	algorithm	: hash algorithm, empty selects the default
+//	seed		: master seed of the filter
+//
+// returns:
+//
+//	Hasher	: hasher for the algorithm
+//	error	: error if the algorithm is unknown
+func NewHasher(algorithm HashAlgorithm, seed uint64) (Hasher, error) {
+	switch algorithm {
+	case HashMurmur3, "":
+		return murmur3Hasher{seed: deriveSeed(seed, 0)}, nil
+	case HashXXHash64:
+		return xxhashHasher{
+			first:  xxhash.NewWithSeed(mix64(seed)),
+			second: xxhash.NewWithSeed(mix64(seed + 1)),
+		}, nil
+	case HashFNV1a:
+		return fnv1aHasher{
+			first:  fnvOffset64 ^ mix64(seed),
+			second: fnvOffset64 ^ mix64(seed+1),
+		}, nil
+	case HashSipHash:
+		return sipHasher{k0: mix64(seed), k1: mix64(seed + 1)}, nil
+	default:
+		return nil, fmt.Errorf("bloom: unknown hash algorithm %q", algorithm)
+	}
+}
+
+type murmur3Hasher struct {
+	seed uint32
+}
+
+func (h murmur3Hasher) Sum128(data []byte) (uint64, uint64) {
+	return murmur3.Sum128WithSeed(data, h.seed)
+}
+
+// xxhashHasher derives h1 and h2 from two independently seeded passes, so
+// items colliding in one half do not collide in the other
+type xxhashHasher struct {
+	// seeded digests copied for every call, xxhash has no seeded one-shot sum
+	first, second *xxhash.Digest
+}
+
+func (h xxhashHasher) Sum128(data []byte) (uint64, uint64) {
+	d1, d2 := *h.first, *h.second
+	d1.Write(data)
+	d2.Write(data)
+	return d1.Sum64(), d2.Sum64()
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// fnv1aHasher derives h1 and h2 from two passes with independently seeded
+// offset bases, so items colliding in one half do not collide in the other.
+// The second pass is finalized with mix64, as FNV-1a passes that only differ
+// in their basis are strongly correlated.
+type fnv1aHasher struct {
+	first, second uint64
+}
+
+func (h fnv1aHasher) Sum128(data []byte) (uint64, uint64) {
+	h1, h2 := h.first, h.second
+	for _, c := range data {
+		h1 ^= uint64(c)
+		h1 *= fnvPrime64
+		h2 ^= uint64(c)
+		h2 *= fnvPrime64
+	}
+	return h1, mix64(h2)
+}
+
+type sipHasher struct {
+	k0, k1 uint64
+}
+
+func (h sipHasher) Sum128(data []byte) (uint64, uint64) {
+	return siphash.Hash128(h.k0, h.k1, data)
+}
@@ -0,0 +1,87 @@
+package bloom
+
+import (
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func TestHashAlgorithms(t *testing.T) {
+	items := test.GenerateStringsOfLength(10, 1000)
+	nonItems := test.GenerateStringsOfLength(12, 1000)
+
+	for _, algorithm := range HashAlgorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			params := CalculateOptimalParameters(len(items), 0.01)
+			params.HashAlgorithm = algorithm
+			filter := New(params)
+
+			if got := filter.GetParameters().HashAlgorithm; got != algorithm {
+				t.Fatalf("Expected hash algorithm %s, got %s", algorithm, got)
+			}
+
+			for _, item := range items {
+				filter.Add(item)
+			}
+			for _, item := range items {
+				if !filter.Exists(item) {
+					t.Fatalf("Expected item %s to exist in the filter", item)
+				}
+			}
+
+			falsePositives := 0
+			for _, item := range nonItems {
+				if filter.Exists(item) {
+					falsePositives++
+				}
+			}
+			// 1% expected, leave room for sampling noise
+			if falsePositives > 40 {
+				t.Fatalf("Expected about 10 false positives, got %d", falsePositives)
+			}
+		})
+	}
+}
+
+func TestHasherSeeded(t *testing.T) {
+	for _, algorithm := range HashAlgorithms {
+		first, _ := NewHasher(algorithm, 1)
+		again, _ := NewHasher(algorithm, 1)
+		other, _ := NewHasher(algorithm, 2)
+
+		h1, h2 := first.Sum128([]byte("apple"))
+		a1, a2 := again.Sum128([]byte("apple"))
+		o1, o2 := other.Sum128([]byte("apple"))
+		if h1 != a1 || h2 != a2 {
+			t.Errorf("%s: expected equal seeds to hash identically", algorithm)
+		}
+		if h1 == o1 && h2 == o2 {
+			t.Errorf("%s: expected different seeds to hash differently", algorithm)
+		}
+	}
+}
+
+func TestHasherHalvesIndependent(t *testing.T) {
+	// h2 must not be derived from h1, or items colliding in h1 would probe
+	// the same bits
+	for _, algorithm := range HashAlgorithms {
+		hasher, _ := NewHasher(algorithm, 1)
+		for _, item := range test.GenerateStringsOfLength(8, 100) {
+			if h1, h2 := hasher.Sum128([]byte(item)); h2 == mix64(h1) {
+				t.Fatalf("%s: expected h2 to be hashed independently of h1", algorithm)
+			}
+		}
+	}
+}
+
+func TestParseHashAlgorithm(t *testing.T) {
+	if algorithm, err := ParseHashAlgorithm(""); err != nil || algorithm != HashMurmur3 {
+		t.Fatalf("Expected default %s, got %s (%v)", HashMurmur3, algorithm, err)
+	}
+	if algorithm, err := ParseHashAlgorithm("xxhash64"); err != nil || algorithm != HashXXHash64 {
+		t.Fatalf("Expected %s, got %s (%v)", HashXXHash64, algorithm, err)
+	}
+	if _, err := ParseHashAlgorithm("md5"); err == nil {
+		t.Fatalf("Expected an error for an unknown algorithm")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/dchest/siphash"
+)
+
+// ErrEmptyKey is returned when rekeying a filter with an empty secret key
+var ErrEmptyKey = errors.New("bloom: secret key must not be empty")
+
+// WithKey Mixes a secret key into every hash of the filter. Without the key
+// an attacker cannot predict which bits an item maps to, so crafted items
+// cannot be used to saturate chosen bits. The key is never part of the
+// filter's Parameters.
+// parameters:
+//
+//	key	: secret key, empty for an unkeyed filter
+//
+// returns:
+//
+//	Option	: option for New
+func WithKey(key []byte) Option {
+	return func(o *options) {
+		o.key = key
+	}
+}
+
+// keyedHasher runs the output of the filter's hash family through SipHash
+// keyed with the secret key, a PRF that hides the item to bit mapping
+type keyedHasher struct {
+	inner  Hasher
+	k0, k1 uint64
+}
+
+func newKeyedHasher(inner Hasher, key []byte) Hasher {
+	if len(key) == 0 {
+		return inner
+	}
+
+	sum := sha256.Sum256(key)
+	return keyedHasher{
+		inner: inner,
+		k0:    binary.LittleEndian.Uint64(sum[0:8]),
+		k1:    binary.LittleEndian.Uint64(sum[8:16]),
+	}
+}
+
+func (h keyedHasher) Sum128(data []byte) (uint64, uint64) {
+	var buf [16]byte
+	h1, h2 := h.inner.Sum128(data)
+	binary.LittleEndian.PutUint64(buf[0:8], h1)
+	binary.LittleEndian.PutUint64(buf[8:16], h2)
+	return siphash.Hash128(h.k0, h.k1, buf[:])
+}
+
+// ReplaySource replays every item that belongs in a filter by calling add
+// for each of them. It is used to rebuild a filter whose bits cannot be
+// carried over, e.g. after its key changed.
+type ReplaySource func(add func(item string)) error
+
+// Rekey Rotates the secret key of the filter and rebuilds its bits from a
+// replayable source. The new bits are built aside and swapped in atomically
+// once the source is exhausted, so lookups keep working during the rebuild.
+// Items added while the rebuild runs are mirrored into the new bits, so only
+// items added and filters merged before Rekey was called must be replayed by
+// the source. On error the filter is left untouched.
+// parameters:
+//
+//	key		: new secret key
+//	source	: source replaying every item of the filter
+//
+// returns:
+//
+//	error	: error if the key is empty or the source fails
+func (b *BloomFilter) Rekey(key []byte, source ReplaySource) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+
+	hasher, err := newFilterHasher(b.params, options{key: key})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rebuilt := newBloomState(b.params, NewPackedBitStore(b.params.Size), hasher)
+	current, before := b.state.Load(), b.added.Load()
+	// from here on adds to the current bits are mirrored into the new ones
+	current.next.Store(rebuilt)
+	var added uint64
+	err = source(func(item string) {
+		added++
+		rebuilt.add(hasher.Sum128(stringBytes(item)))
+	})
+	if err != nil {
+		current.next.Store(nil)
+		return err
+	}
+
+	b.state.Store(rebuilt)
+	b.added.Store(added + b.added.Load() - before)
+	return nil
+}
@@ -0,0 +1,131 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func TestWithKey(t *testing.T) {
+	params := CalculateOptimalParameters(100, 0.01)
+	plain := New(params)
+	keyed := New(params, WithKey([]byte("secret")))
+	same := New(params, WithKey([]byte("secret")))
+	other := New(params, WithKey([]byte("other secret")))
+
+	p1, p2 := plain.doHash("apple")
+	k1, k2 := keyed.doHash("apple")
+	s1, s2 := same.doHash("apple")
+	o1, o2 := other.doHash("apple")
+
+	if k1 != s1 || k2 != s2 {
+		t.Fatalf("Expected filters with the same key to hash identically")
+	}
+	if k1 == p1 && k2 == p2 {
+		t.Fatalf("Expected the key to change the hash")
+	}
+	if k1 == o1 && k2 == o2 {
+		t.Fatalf("Expected different keys to hash differently")
+	}
+
+	keyed.Add("apple")
+	if !keyed.Exists("apple") {
+		t.Fatalf("Expected item apple to exist in the keyed filter")
+	}
+}
+
+func TestKeyNotInParameters(t *testing.T) {
+	key := []byte("do-not-leak-me")
+	filter := New(CalculateOptimalParameters(100, 0.01), WithKey(key))
+
+	body, err := json.Marshal(filter.GetParameters())
+	if err != nil {
+		t.Fatalf("Failed to marshal parameters: %v", err)
+	}
+	if bytes.Contains(body, key) {
+		t.Fatalf("Expected parameters not to contain the key: %s", body)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	items := test.GenerateStringsOfLength(10, 100)
+	params := CalculateOptimalParameters(len(items), 0.01)
+	filter := New(params, WithKey([]byte("old")))
+	for _, item := range items {
+		filter.Add(item)
+	}
+	before, _ := filter.doHash(items[0])
+
+	source := func(add func(item string)) error {
+		for _, item := range items {
+			add(item)
+		}
+		return nil
+	}
+	if err := filter.Rekey([]byte("new"), source); err != nil {
+		t.Fatalf("Failed to rekey: %v", err)
+	}
+
+	after, _ := filter.doHash(items[0])
+	if before == after {
+		t.Fatalf("Expected rekeying to change the hash")
+	}
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist after rekeying", item)
+		}
+	}
+	if got := filter.GetStatistics().AddedItems; got != uint64(len(items)) {
+		t.Fatalf("Expected %d added items, got %d", len(items), got)
+	}
+}
+
+func TestRekeyKeepsConcurrentAdds(t *testing.T) {
+	filter := New(CalculateOptimalParameters(100, 0.01), WithKey([]byte("old")))
+	filter.Add("apple")
+
+	// adds made while the source replays land in the old bits first
+	err := filter.Rekey([]byte("new"), func(add func(item string)) error {
+		add("apple")
+		filter.Add("banana")
+		filter.AddMany([]string{"cherry", "date"})
+		filter.AddIfAbsent("elderberry")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to rekey: %v", err)
+	}
+
+	for _, item := range []string{"apple", "banana", "cherry", "date", "elderberry"} {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s added during the rekey to exist", item)
+		}
+	}
+	if got := filter.GetStatistics().AddedItems; got != 5 {
+		t.Fatalf("Expected 5 added items, got %d", got)
+	}
+}
+
+func TestRekeyFailureKeepsFilter(t *testing.T) {
+	filter := New(CalculateOptimalParameters(100, 0.01), WithKey([]byte("old")))
+	filter.Add("apple")
+
+	if err := filter.Rekey(nil, nil); !errors.Is(err, ErrEmptyKey) {
+		t.Fatalf("Expected ErrEmptyKey, got %v", err)
+	}
+
+	failure := errors.New("source unavailable")
+	err := filter.Rekey([]byte("new"), func(add func(item string)) error {
+		add("banana")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the source error, got %v", err)
+	}
+	if !filter.Exists("apple") {
+		t.Fatalf("Expected the filter to be untouched after a failed rekey")
+	}
+}
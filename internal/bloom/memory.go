@@ -0,0 +1,111 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxFootprintBits is the largest size Footprint accepts, far beyond any
+// machine's memory but small enough that no footprint overflows
+const maxFootprintBits = 1 << 56
+
+// Footprint Returns the memory a filter created from params will hold,
+// without creating it, so a caller can refuse a filter that does not fit
+// before allocating it. For a rotating filter it covers every generation,
+// which are allocated as it rotates; for a scalable filter only the first
+// layer, later layers are allocated as it grows.
+// parameters:
+//
+//	params	: parameters of the filter, as passed to NewFilter
+//
+// returns:
+//
+//	uint64	: memory the filter will hold in bytes, as SizeInBytes reports it
+//	error	: error if the parameters are invalid
+func Footprint(params Parameters) (uint64, error) {
+	if params.Type == TypeCuckoo {
+		params, _, err := cuckooLayout(params)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkFootprintSize(params.Size); err != nil {
+			return 0, err
+		}
+		return denseLen(params.Size), nil
+	}
+	if params.Type == TypeSharded {
+		params, shardSize := shardedLayout(params)
+		if err := checkFootprintSize(params.Size); err != nil {
+			return 0, err
+		}
+		return shardedBytes(uint64(params.Shards), shardSize), nil
+	}
+
+	if err := checkFootprintSize(params.Size); err != nil {
+		return 0, err
+	}
+
+	switch params.Type {
+	case TypeStandard, "":
+		return denseLen(params.Size) + stripeBytes, nil
+	case TypeCounting:
+		if params.CounterWidth == 0 {
+			params.CounterWidth = DefaultCounterWidth
+		}
+		if err := checkCounterWidth(params.CounterWidth); err != nil {
+			return 0, err
+		}
+		perWord := wordBits / uint64(params.CounterWidth)
+		return (params.Size + perWord - 1) / perWord * 8, nil
+	case TypeScalable:
+		if params.Capacity == 0 {
+			return 0, errors.New("bloom: scalable filter needs a capacity")
+		}
+		if params.GrowthFactor == 0 {
+			params.GrowthFactor = DefaultGrowthFactor
+		}
+		if params.TighteningRatio == 0 {
+			params.TighteningRatio = DefaultTighteningRatio
+		}
+		layer := layerParameters(params, 0)
+		if err := checkFootprintSize(layer.Size); err != nil {
+			return 0, err
+		}
+		return denseLen(layer.Size) + stripeBytes, nil
+	case TypeRotating:
+		generations := uint64(params.Generations)
+		if generations == 0 {
+			generations = DefaultGenerations
+		}
+		return generations * (denseLen(params.Size) + stripeBytes), nil
+	case TypeBlocked:
+		return blockedBytes((params.Size + blockBits - 1) / blockBits), nil
+	default:
+		return 0, fmt.Errorf("bloom: unknown filter type %q", params.Type)
+	}
+}
+
+// checkFootprintSize Checks that a filter of size bits can be accounted for
+func checkFootprintSize(size uint64) error {
+	if size == 0 {
+		return errors.New("bloom: size must be greater than 0")
+	}
+	if size > maxFootprintBits {
+		return fmt.Errorf("bloom: size %d is too large", size)
+	}
+	return nil
+}
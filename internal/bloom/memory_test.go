@@ -0,0 +1,82 @@
+package bloom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFootprint(t *testing.T) {
+	for _, filterType := range FilterTypes {
+		params := CalculateOptimalParameters(10_000, 0.01)
+		params.Type = filterType
+		params.RotationItems = 100
+		footprint, err := Footprint(params)
+		if err != nil {
+			t.Fatalf("Failed to compute the footprint of a %s filter: %v", filterType, err)
+		}
+
+		filter, err := NewFilter(params)
+		if err != nil {
+			t.Fatalf("Failed to create %s filter: %v", filterType, err)
+		}
+		size := filter.SizeInBytes()
+		if size < params.Size/8 {
+			t.Fatalf("Expected a %s filter of %d bits to hold at least %d bytes, got %d", filterType, params.Size, params.Size/8, size)
+		}
+
+		// a rotating filter allocates its generations as it rotates
+		if filterType == TypeRotating {
+			size *= DefaultGenerations
+		}
+		if size != footprint {
+			t.Fatalf("Expected a %s filter to hold its footprint of %d bytes, got %d", filterType, footprint, size)
+		}
+	}
+}
+
+func TestFootprintGrows(t *testing.T) {
+	params := CalculateOptimalParameters(100, 0.01)
+	params.Type = TypeRotating
+	params.RotationInterval = time.Hour
+	now := time.Unix(0, 0)
+	rotating, err := NewRotating(params, WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("Failed to create rotating filter: %v", err)
+	}
+	one := rotating.SizeInBytes()
+	now = now.Add(time.Hour)
+	rotating.Add("apple")
+	if got := rotating.SizeInBytes(); got != 2*one {
+		t.Fatalf("Expected two generations to hold %d bytes, got %d", 2*one, got)
+	}
+
+	scalable, err := NewScalable(CalculateOptimalParameters(100, 0.01))
+	if err != nil {
+		t.Fatalf("Failed to create scalable filter: %v", err)
+	}
+	first := scalable.SizeInBytes()
+	for i := range 1000 {
+		scalable.Add(string(rune(i)))
+	}
+	if got := scalable.SizeInBytes(); got <= first {
+		t.Fatalf("Expected a grown scalable filter to hold more than %d bytes, got %d", first, got)
+	}
+}
+
+func TestFootprintInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Parameters
+	}{
+		{"zero size", Parameters{NumHashFunctions: 3}},
+		{"too large", Parameters{Size: 1 << 62, NumHashFunctions: 3}},
+		{"bad counter width", Parameters{Size: 1000, NumHashFunctions: 3, Type: TypeCounting, CounterWidth: 200}},
+		{"unknown type", Parameters{Size: 1000, NumHashFunctions: 3, Type: "quotient"}},
+		{"huge cuckoo", Parameters{Type: TypeCuckoo, Capacity: 1 << 62, FalsePositiveRate: 0.01}},
+	}
+	for _, tc := range tests {
+		if _, err := Footprint(tc.params); err == nil {
+			t.Errorf("Expected an error for %s", tc.name)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrIncompatible is returned when merging filters that do not map items to
+// the same bits
+var ErrIncompatible = errors.New("bloom: filters are incompatible")
+
+// Union Merges the bits of other into the filter, so it reports every item
+// added to either filter. Both filters must share size, number of hash
+// functions, hash algorithm, seed and secret key.
+// parameters:
+//
+//	other	: filter to merge into the bloom filter
+//
+// returns:
+//
+//	error	: ErrIncompatible if the filters map items to different bits
+func (b *BloomFilter) Union(other *BloomFilter) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st, src := b.state.Load(), other.state.Load()
+	if err := checkCompatible(b.params, other.params, st.hasher, src.hasher); err != nil {
+		return err
+	}
+
+	if dst, ok := st.bits.(*PackedBitStore); ok {
+		if words, ok := src.bits.(*PackedBitStore); ok {
+			dst.or(words)
+			st.setBits.Store(dst.count())
+			b.added.Add(other.added.Load())
+			return nil
+		}
+	}
+
+	// bit by bit for other stores
+	for i := range st.size {
+		if src.bits.Test(i) && st.bits.Set(i) {
+			st.setBits.Add(1)
+		}
+	}
+	b.added.Add(other.added.Load())
+	return nil
+}
+
+// Intersect Keeps only the bits set in both filters. The result reports
+// every item added to both filters, with a false positive rate at most that
+// of either filter. Both filters must be compatible as for Union and backed
+// by a PackedBitStore.
+// parameters:
+//
+//	other	: filter to intersect the bloom filter with
+//
+// returns:
+//
+//	error	: ErrIncompatible if the filters map items to different bits
+func (b *BloomFilter) Intersect(other *BloomFilter) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st, src := b.state.Load(), other.state.Load()
+	if err := checkCompatible(b.params, other.params, st.hasher, src.hasher); err != nil {
+		return err
+	}
+
+	dst, ok := st.bits.(*PackedBitStore)
+	if !ok {
+		return fmt.Errorf("%w: cannot intersect a %T", ErrIncompatible, st.bits)
+	}
+	words, ok := src.bits.(*PackedBitStore)
+	if !ok {
+		return fmt.Errorf("%w: cannot intersect with a %T", ErrIncompatible, src.bits)
+	}
+
+	dst.and(words)
+	st.setBits.Store(dst.count())
+	// neither count is exact any more, the smaller one is an upper bound
+	b.added.Store(min(b.added.Load(), other.added.Load()))
+	return nil
+}
+
+// Merge Merges serialized bits into a live filter, e.g. a filter built on
+// another node. The bits are interpreted with params and opts, so they must
+// come from a filter compatible with live.
+// parameters:
+//
+//	live		: filter to merge the bits into, must be a *BloomFilter
+//	params		: parameters of the filter the bits were taken from
+//	data		: packed bits as returned by PackedBitStore.Bytes
+//	intersect	: intersect with the bits instead of taking the union
+//	opts		: options live was created with, e.g. WithKey
+//
+// returns:
+//
+//	error	: ErrIncompatible if the bits cannot be merged
+func Merge(live ProbabilisticFilter, params Parameters, data []byte, intersect bool, opts ...Option) error {
+	dst, ok := live.(*BloomFilter)
+	if !ok {
+		return fmt.Errorf("%w: cannot merge into a %s filter", ErrIncompatible, live.GetParameters().Type)
+	}
+	if params.Type != "" && params.Type != TypeStandard {
+		return fmt.Errorf("%w: cannot merge a %s filter", ErrIncompatible, params.Type)
+	}
+	if params.HashAlgorithm == "" {
+		params.HashAlgorithm = HashMurmur3
+	}
+	if err := checkCompatible(dst.params, params, nil, nil); err != nil {
+		return err
+	}
+
+	store, err := NewPackedBitStoreFromBytes(params.Size, data)
+	if err != nil {
+		return err
+	}
+	return mergeInto(dst, NewWithStore(params, store, opts...), intersect)
+}
+
+// CheckMergeable Checks that the serialized filter at the head of r can be
+// merged into live, from its header alone and without consuming it, so an
+// incompatible upload is refused before it is decoded
+// parameters:
+//
+//	live	: filter the serialized filter is to be merged into
+//	r		: buffered reader holding a serialized filter
+//
+// returns:
+//
+//	error	: ErrInvalidFormat if r does not start with a valid header,
+//			  ErrIncompatible if the filters map items to different bits
+func CheckMergeable(live ProbabilisticFilter, r *bufio.Reader) error {
+	dst, ok := live.(*BloomFilter)
+	if !ok {
+		return fmt.Errorf("%w: cannot merge into a %s filter", ErrIncompatible, live.GetParameters().Type)
+	}
+
+	header, err := r.Peek(headerSize)
+	if err != nil {
+		return fmt.Errorf("%w: reading header: %w", ErrInvalidFormat, err)
+	}
+	params, _, _, check, err := parseHeader(header)
+	if err != nil {
+		return err
+	}
+	if err := checkCompatible(dst.params, params, nil, nil); err != nil {
+		return err
+	}
+	if keyCheck(dst.state.Load().hasher) != check {
+		return fmt.Errorf("%w: secret keys differ", ErrIncompatible)
+	}
+	return nil
+}
+
+// mergeInto Takes the union or intersection of live and other
+func mergeInto(live, other *BloomFilter, intersect bool) error {
+	if intersect {
+		return live.Intersect(other)
+	}
+	return live.Union(other)
+}
+
+// checkCompatible Checks that two filters map every item to the same bits.
+// The hashers are compared to tell apart filters with different secret keys,
+// nil hashers skip that check.
+func checkCompatible(p, q Parameters, h, g Hasher) error {
+	switch {
+	case p.Size != q.Size:
+		return fmt.Errorf("%w: size %d != %d", ErrIncompatible, p.Size, q.Size)
+	case p.NumHashFunctions != q.NumHashFunctions:
+		return fmt.Errorf("%w: hash functions %d != %d", ErrIncompatible, p.NumHashFunctions, q.NumHashFunctions)
+	case p.HashAlgorithm != q.HashAlgorithm:
+		return fmt.Errorf("%w: hash algorithm %s != %s", ErrIncompatible, p.HashAlgorithm, q.HashAlgorithm)
+	case p.Seed != q.Seed:
+		return fmt.Errorf("%w: seed %d != %d", ErrIncompatible, p.Seed, q.Seed)
+	case h != nil && g != nil && !sameKey(h, g):
+		return fmt.Errorf("%w: secret keys differ", ErrIncompatible)
+	}
+	return nil
+}
+
+// sameKey Reports whether two hashers use the same secret key, comparing
+// the keys in constant time
+func sameKey(h, g Hasher) bool {
+	kh, hKeyed := h.(keyedHasher)
+	kg, gKeyed := g.(keyedHasher)
+	if !hKeyed || !gKeyed {
+		return hKeyed == gKeyed
+	}
+
+	var a, b [16]byte
+	binary.LittleEndian.PutUint64(a[0:8], kh.k0)
+	binary.LittleEndian.PutUint64(a[8:16], kh.k1)
+	binary.LittleEndian.PutUint64(b[0:8], kg.k0)
+	binary.LittleEndian.PutUint64(b[8:16], kg.k1)
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
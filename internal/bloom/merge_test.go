@@ -0,0 +1,166 @@
+package bloom
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func TestUnion(t *testing.T) {
+	params := CalculateOptimalParameters(1000, 0.01)
+	a, b := New(params), New(params)
+	left := test.GenerateStringsOfLength(10, 500)
+	right := test.GenerateStringsOfLength(12, 500)
+	for _, item := range left {
+		a.Add(item)
+	}
+	for _, item := range right {
+		b.Add(item)
+	}
+
+	if err := a.Union(b); err != nil {
+		t.Fatalf("Failed to union filters: %v", err)
+	}
+	for _, item := range append(left, right...) {
+		if !a.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the union", item)
+		}
+	}
+	if got, want := a.state.Load().setBits.Load(), a.state.Load().bits.(*PackedBitStore).count(); got != want {
+		t.Fatalf("Expected %d set bits after the union, got %d", want, got)
+	}
+	if added := a.GetStatistics().AddedItems; added != 1000 {
+		t.Fatalf("Expected 1000 added items, got %d", added)
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	params := CalculateOptimalParameters(1000, 0.01)
+	a, b := New(params), New(params)
+	shared := test.GenerateStringsOfLength(8, 200)
+	only := test.GenerateStringsOfLength(10, 500)
+	for _, item := range shared {
+		a.Add(item)
+		b.Add(item)
+	}
+	for _, item := range only {
+		a.Add(item)
+	}
+
+	if err := a.Intersect(b); err != nil {
+		t.Fatalf("Failed to intersect filters: %v", err)
+	}
+	for _, item := range shared {
+		if !a.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the intersection", item)
+		}
+	}
+
+	falsePositives := 0
+	for _, item := range only {
+		if a.Exists(item) {
+			falsePositives++
+		}
+	}
+	if falsePositives > len(only)/20 {
+		t.Fatalf("Expected items of one filter to be dropped, %d of %d remain", falsePositives, len(only))
+	}
+	if got, want := a.state.Load().setBits.Load(), b.state.Load().setBits.Load(); got != want {
+		t.Fatalf("Expected the intersection to keep the %d bits of the subset, got %d", want, got)
+	}
+}
+
+func TestUnionSparseStore(t *testing.T) {
+	params := CalculateOptimalParameters(100, 0.01)
+	a, b := NewWithStore(params, &sparseBitStore{size: params.Size, bits: map[uint64]bool{}}), New(params)
+	b.Add("apple")
+
+	if err := a.Union(b); err != nil {
+		t.Fatalf("Failed to union filters: %v", err)
+	}
+	if !a.Exists("apple") {
+		t.Fatalf("Expected item apple to exist in the union")
+	}
+	if err := a.Intersect(b); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("Expected intersecting a sparse store to fail, got %v", err)
+	}
+}
+
+func TestMergeIncompatible(t *testing.T) {
+	params := CalculateOptimalParameters(1000, 0.01)
+	base := New(params)
+
+	tests := []struct {
+		name  string
+		other *BloomFilter
+	}{
+		{"size", New(CalculateOptimalParameters(2000, 0.01))},
+		{"hash functions", New(Parameters{Size: params.Size, NumHashFunctions: params.NumHashFunctions + 1})},
+		{"hash algorithm", New(Parameters{Size: params.Size, NumHashFunctions: params.NumHashFunctions, HashAlgorithm: HashXXHash64})},
+		{"seed", New(Parameters{Size: params.Size, NumHashFunctions: params.NumHashFunctions, Seed: 1})},
+		{"key", New(params, WithKey([]byte("secret")))},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := base.Union(tc.other); !errors.Is(err, ErrIncompatible) {
+				t.Fatalf("Expected union to be refused, got %v", err)
+			}
+			if err := base.Intersect(tc.other); !errors.Is(err, ErrIncompatible) {
+				t.Fatalf("Expected intersection to be refused, got %v", err)
+			}
+
+			// the serialized filter is refused from its header, which is
+			// left unread
+			data, _ := tc.other.MarshalBinary()
+			r := bufio.NewReader(bytes.NewReader(data))
+			if err := CheckMergeable(base, r); !errors.Is(err, ErrIncompatible) {
+				t.Fatalf("Expected the serialized filter to be refused, got %v", err)
+			}
+			if r.Buffered() != len(data) {
+				t.Fatalf("Expected the serialized filter to be left unread")
+			}
+		})
+	}
+
+	keyed, other := New(params, WithKey([]byte("secret"))), New(params, WithKey([]byte("secret")))
+	if err := keyed.Union(other); err != nil {
+		t.Fatalf("Expected filters with the same key to merge, got %v", err)
+	}
+	data, _ := other.MarshalBinary()
+	if err := CheckMergeable(keyed, bufio.NewReader(bytes.NewReader(data))); err != nil {
+		t.Fatalf("Expected the serialized filter with the same key to be mergeable, got %v", err)
+	}
+	counting, _ := NewCounting(params)
+	if err := CheckMergeable(counting, bufio.NewReader(bytes.NewReader(data))); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("Expected merging into a counting filter to be refused, got %v", err)
+	}
+}
+
+func TestPackedBitStoreBytes(t *testing.T) {
+	store := NewPackedBitStore(100)
+	store.Set(0)
+	store.Set(99)
+
+	restored, err := NewPackedBitStoreFromBytes(100, store.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to restore bit store: %v", err)
+	}
+	if !restored.Test(0) || !restored.Test(99) || restored.count() != 2 {
+		t.Fatalf("Expected bits 0 and 99 to be restored, got %d set bits", restored.count())
+	}
+
+	if _, err := NewPackedBitStoreFromBytes(100, []byte{1, 2, 3}); err == nil {
+		t.Fatalf("Expected truncated bits to be refused")
+	}
+
+	// bits beyond the size are dropped
+	data := store.Bytes()
+	data[len(data)-1] = 0xff
+	restored, _ = NewPackedBitStoreFromBytes(100, data)
+	if restored.count() != 2 {
+		t.Fatalf("Expected bits beyond the size to be dropped, got %d set bits", restored.count())
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import "time"
+
+// Option configures optional behaviour of a filter at creation
+type Option func(*options)
+
+type options struct {
+	// secret key mixed into every hash, nil for an unkeyed filter
+	key []byte
+
+	// clock of time based behaviour, time.Now if unset
+	now func() time.Time
+
+	// largest serialized filter accepted by ReadFilter in bits
+	maxSize uint64
+
+	// check a scalable filter makes before it allocates a new layer, nil to
+	// grow without bound
+	growth func(bytes uint64) error
+}
+
+// WithClock Replaces the clock a filter uses for time based behaviour, such
+// as the rotation of a rotating filter
+// parameters:
+//
+//	now	: function returning the current time
+//
+// returns:
+//
+//	Option	: option for New
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithMaxSize Limits the size of the filters ReadFilter accepts, so an
+// untrusted header cannot make it allocate more than the caller expects
+// parameters:
+//
+//	bits	: largest size in bits, e.g. the size of the filter merged into
+//
+// returns:
+//
+//	Option	: option for ReadFilter and ReadExactly
+func WithMaxSize(bits uint64) Option {
+	return func(o *options) {
+		o.maxSize = bits
+	}
+}
+
+// WithGrowth Sets the check a scalable filter makes before it allocates a
+// new layer, e.g. against a memory limit. It is called without the filter's
+// lock held, so it may inspect the filter.
+// parameters:
+//
+//	allow	: function given the bytes of the new layer, an error refuses it
+//
+// returns:
+//
+//	Option	: option for NewScalable
+func WithGrowth(allow func(bytes uint64) error) Option {
+	return func(o *options) {
+		o.growth = allow
+	}
+}
+
+func buildOptions(opts []Option) options {
+	o := options{now: time.Now, maxSize: maxFootprintBits}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,414 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultGenerations is the number of live generations of a rotating filter
+// when Parameters.Generations is unset
+const DefaultGenerations = 2
+
+// GenerationStatistics describes one generation of a rotating filter
+type GenerationStatistics struct {
+	// Time the generation started
+	Started time.Time `json:"started"`
+	// Time the next generation started, zero for the newest generation
+	Ended time.Time `json:"ended,omitempty"`
+	// Age of the generation in seconds
+	AgeSeconds float64 `json:"age_seconds"`
+	// Number of items added to the generation
+	AddedItems uint64 `json:"added_items"`
+	// Fraction of the bits of the generation that are set
+	FillRatio float64 `json:"fill_ratio"`
+}
+
+// generation is one BloomFilter of a rotating filter
+type generation struct {
+	filter  *BloomFilter
+	started time.Time
+}
+
+// RotatingBloomFilter keeps the items of the last Generations generations,
+// giving items a time to live. Items are added to the newest generation;
+// once it is RotationInterval old or holds RotationItems items a new
+// generation starts and the oldest one is dropped. Exists checks every live
+// generation, so an item is remembered for at least Generations-1 and at
+// most Generations rotation periods.
+type RotatingBloomFilter struct {
+	// parameters for the rotating filter, Size is the size of one generation
+	params Parameters
+
+	// statistics for the rotating filter
+	stats Statistics
+
+	// live generations, oldest first
+	generations []generation
+
+	// options every generation is created with
+	opts []Option
+
+	// clock the rotation is scheduled by
+	now func() time.Time
+
+	// mutex for concurrent access
+	mu *sync.RWMutex
+}
+
+// NewRotating Creates a new RotatingBloomFilter whose generations each hold
+// params.Size bits
+// parameters:
+//
+//	params	: parameters of the rotating filter
+//	opts	: optional behaviour, e.g. WithKey or WithClock
+//
+// returns:
+//
+//	*RotatingBloomFilter	: pointer to the RotatingBloomFilter struct
+//	error					: error if the parameters are invalid
+func NewRotating(params Parameters, opts ...Option) (*RotatingBloomFilter, error) {
+	if params.Generations == 0 {
+		params.Generations = DefaultGenerations
+	}
+	if params.Generations < 2 {
+		return nil, errors.New("bloom: rotating filter needs at least 2 generations")
+	}
+	if params.RotationInterval <= 0 && params.RotationItems == 0 {
+		return nil, errors.New("bloom: rotating filter needs a rotation interval or item count")
+	}
+	if _, err := NewHasher(params.HashAlgorithm, params.Seed); err != nil {
+		return nil, err
+	}
+	if params.HashAlgorithm == "" {
+		params.HashAlgorithm = HashMurmur3
+	}
+	params.Type = TypeRotating
+
+	r := &RotatingBloomFilter{
+		params: params,
+		opts:   opts,
+		now:    buildOptions(opts).now,
+		mu:     &sync.RWMutex{},
+	}
+	r.generations = []generation{r.newGeneration(r.now())}
+	return r, nil
+}
+
+// Add Adds an item to the newest generation, rotating first if it is due
+// parameters:
+//
+//	item	: item to add to the rotating filter
+//
+// returns:
+//
+//	none
+func (r *RotatingBloomFilter) Add(item string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rotate(r.now())
+	newest := r.newest()
+	newest.addHashed(newest.doHash(item))
+}
+
+// AddMany Adds a batch of items to the rotating filter under a single lock
+// parameters:
+//
+//	items	: items to add to the rotating filter
+//
+// returns:
+//
+//	none
+func (r *RotatingBloomFilter) AddMany(items []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rotate(r.now())
+	for _, item := range items {
+		newest := r.newest()
+		newest.addHashed(newest.doHash(item))
+	}
+}
+
+// ExistsMany Checks a batch of items in the rotating filter under a single lock
+// parameters:
+//
+//	items	: items to check in the rotating filter
+//
+// returns:
+//
+//	[]bool	: for every item, true if it is in the rotating filter, in the order of items
+func (r *RotatingBloomFilter) ExistsMany(items []string) []bool {
+	r.rotateIfDue()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	atomic.AddUint64(&r.stats.CheckedItems, uint64(len(items)))
+	exists := make([]bool, len(items))
+	for i, item := range items {
+		h1, h2 := r.generations[0].filter.doHash(item)
+		for _, gen := range r.generations {
+			if gen.filter.existsHashed(h1, h2) {
+				exists[i] = true
+				break
+			}
+		}
+	}
+	return exists
+}
+
+// AddIfAbsent Adds an item to the newest generation unless a live generation
+// already has it, and reports whether one did. A present item keeps the age
+// of the generation it was added in.
+// parameters:
+//
+//	item	: item to add to the rotating filter
+//
+// returns:
+//
+//	bool	: true if the item was already in the rotating filter, false otherwise
+func (r *RotatingBloomFilter) AddIfAbsent(item string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rotate(r.now())
+	r.stats.CheckedItems++
+	h1, h2 := r.generations[0].filter.doHash(item)
+	for _, gen := range r.generations {
+		if gen.filter.existsHashed(h1, h2) {
+			return true
+		}
+	}
+
+	r.newest().addHashed(h1, h2)
+	return false
+}
+
+// newest Returns the generation to add an item to, starting a new one if the
+// newest is full, and counts the item
+func (r *RotatingBloomFilter) newest() *BloomFilter {
+	newest := r.generations[len(r.generations)-1].filter
+	if r.params.RotationItems > 0 && newest.added.Load() >= r.params.RotationItems {
+		r.advance(r.now())
+		newest = r.generations[len(r.generations)-1].filter
+	}
+
+	r.stats.AddedItems++
+	newest.added.Add(1)
+	return newest
+}
+
+// Exists Checks if an item is in any live generation
+// parameters:
+//
+//	item	: item to check in the rotating filter
+//
+// returns:
+//
+//	bool	: true if the item is in the rotating filter, false otherwise
+func (r *RotatingBloomFilter) Exists(item string) bool {
+	r.rotateIfDue()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	atomic.AddUint64(&r.stats.CheckedItems, 1)
+	h1, h2 := r.generations[0].filter.doHash(item)
+	for i := len(r.generations) - 1; i >= 0; i-- {
+		if r.generations[i].filter.existsHashed(h1, h2) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddBytes Adds a binary item to the rotating filter, see BytesFilter
+// parameters:
+//
+//	item	: item to add to the rotating filter
+//
+// returns:
+//
+//	none
+func (r *RotatingBloomFilter) AddBytes(item []byte) {
+	r.Add(bytesString(item))
+}
+
+// ExistsBytes Checks if a binary item is in the rotating filter, see BytesFilter
+// parameters:
+//
+//	item	: item to check in the rotating filter
+//
+// returns:
+//
+//	bool	: true if the item is in the rotating filter, false otherwise
+func (r *RotatingBloomFilter) ExistsBytes(item []byte) bool {
+	return r.Exists(bytesString(item))
+}
+
+// Clear Clears the rotating filter, dropping every generation
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	none
+func (r *RotatingBloomFilter) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generations = []generation{r.newGeneration(r.now())}
+}
+
+// Rotate Starts a new generation now, dropping the oldest one if all
+// generations are live
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	none
+func (r *RotatingBloomFilter) Rotate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.advance(r.now())
+}
+
+func (r *RotatingBloomFilter) GetParameters() Parameters {
+	return r.params
+}
+
+func (r *RotatingBloomFilter) GetStatistics() Statistics {
+	r.rotateIfDue()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	stats := Statistics{
+		AddedItems:   r.stats.AddedItems,
+		CheckedItems: atomic.LoadUint64(&r.stats.CheckedItems),
+		Generations:  make([]GenerationStatistics, 0, len(r.generations)),
+	}
+	for i, gen := range r.generations {
+		// an item added in several generations is counted once per generation
+		stats.addEstimates(gen.filter.GetStatistics())
+
+		generation := GenerationStatistics{
+			Started:    gen.started,
+			AgeSeconds: now.Sub(gen.started).Seconds(),
+			AddedItems: gen.filter.added.Load(),
+			FillRatio:  gen.filter.FillRatio(),
+		}
+		if i+1 < len(r.generations) {
+			generation.Ended = r.generations[i+1].started
+		}
+		stats.Generations = append(stats.Generations, generation)
+	}
+
+	return stats
+}
+
+// SizeInBytes Returns the memory held by the live generations of the rotating filter
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	uint64	: size of the rotating filter in bytes
+func (r *RotatingBloomFilter) SizeInBytes() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var size uint64
+	for _, g := range r.generations {
+		size += g.filter.SizeInBytes()
+	}
+	return size
+}
+
+func (r *RotatingBloomFilter) String() string {
+	return fmt.Sprintf("RotatingBloomFilter{size: %d, generations: %d/%d}", r.params.Size, len(r.generations), r.params.Generations)
+}
+
+// rotateIfDue Rotates under the write lock if the newest generation expired
+func (r *RotatingBloomFilter) rotateIfDue() {
+	if r.params.RotationInterval <= 0 {
+		return
+	}
+
+	now := r.now()
+	r.mu.RLock()
+	due := now.Sub(r.generations[len(r.generations)-1].started) >= r.params.RotationInterval
+	r.mu.RUnlock()
+
+	if due {
+		r.mu.Lock()
+		r.rotate(now)
+		r.mu.Unlock()
+	}
+}
+
+// rotate Starts one generation per elapsed rotation interval, the caller
+// holds the write lock. Generations start on interval boundaries, so idle
+// periods expire items as if the filter had rotated on schedule.
+func (r *RotatingBloomFilter) rotate(now time.Time) {
+	if r.params.RotationInterval <= 0 {
+		return
+	}
+
+	last := r.generations[len(r.generations)-1].started
+	elapsed := int64(now.Sub(last) / r.params.RotationInterval)
+	if elapsed <= 0 {
+		return
+	}
+
+	if elapsed >= int64(r.params.Generations) {
+		// every live generation expired
+		started := last.Add(time.Duration(elapsed) * r.params.RotationInterval)
+		r.generations = []generation{r.newGeneration(started)}
+		return
+	}
+	for i := range elapsed {
+		r.advance(last.Add(time.Duration(i+1) * r.params.RotationInterval))
+	}
+}
+
+// advance Starts a new generation at started, the caller holds the write lock
+func (r *RotatingBloomFilter) advance(started time.Time) {
+	r.generations = append(r.generations, r.newGeneration(started))
+	if len(r.generations) > int(r.params.Generations) {
+		r.generations = r.generations[1:]
+	}
+}
+
+func (r *RotatingBloomFilter) newGeneration(started time.Time) generation {
+	params := r.params
+	params.Type = TypeStandard
+	return generation{
+		filter:  New(params, r.opts...),
+		started: started,
+	}
+}
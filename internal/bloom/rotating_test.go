@@ -0,0 +1,122 @@
+package bloom
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for rotation tests
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func rotatingParams(generations uint8) Parameters {
+	params := CalculateOptimalParameters(1000, 0.01)
+	params.Type = TypeRotating
+	params.Generations = generations
+	return params
+}
+
+func TestRotatingInterval(t *testing.T) {
+	clock := newFakeClock()
+	params := rotatingParams(3)
+	params.RotationInterval = time.Hour
+	filter, err := NewRotating(params, WithClock(clock.Now))
+	if err != nil {
+		t.Fatalf("Failed to create rotating filter: %v", err)
+	}
+
+	filter.Add("apple")
+	clock.Advance(time.Hour)
+	filter.Add("banana")
+	clock.Advance(time.Hour)
+
+	if !filter.Exists("apple") || !filter.Exists("banana") {
+		t.Fatalf("Expected items of the last 3 generations to exist")
+	}
+
+	clock.Advance(time.Hour)
+	if filter.Exists("apple") {
+		t.Fatalf("Expected apple to expire with its generation")
+	}
+	if !filter.Exists("banana") {
+		t.Fatalf("Expected banana to still exist")
+	}
+
+	stats := filter.GetStatistics()
+	if len(stats.Generations) != 3 {
+		t.Fatalf("Expected 3 generations, got %d", len(stats.Generations))
+	}
+	oldest, newest := stats.Generations[0], stats.Generations[2]
+	if oldest.AddedItems != 1 || oldest.AgeSeconds != (2*time.Hour).Seconds() {
+		t.Fatalf("Expected the oldest generation to hold banana and be 2h old, got %+v", oldest)
+	}
+	if !oldest.Ended.Equal(stats.Generations[1].Started) || !newest.Ended.IsZero() {
+		t.Fatalf("Expected generation boundaries to line up, got %+v", stats.Generations)
+	}
+}
+
+func TestRotatingIdleExpiresEverything(t *testing.T) {
+	clock := newFakeClock()
+	params := rotatingParams(2)
+	params.RotationInterval = time.Minute
+	filter, err := NewRotating(params, WithClock(clock.Now))
+	if err != nil {
+		t.Fatalf("Failed to create rotating filter: %v", err)
+	}
+
+	filter.Add("apple")
+	clock.Advance(10*time.Minute + time.Second)
+
+	if filter.Exists("apple") {
+		t.Fatalf("Expected apple to expire after an idle period")
+	}
+	stats := filter.GetStatistics()
+	if len(stats.Generations) != 1 || stats.Generations[0].AgeSeconds != 1 {
+		t.Fatalf("Expected a single generation aligned to the schedule, got %+v", stats.Generations)
+	}
+}
+
+func TestRotatingItems(t *testing.T) {
+	params := rotatingParams(2)
+	params.RotationItems = 2
+	filter, err := NewRotating(params)
+	if err != nil {
+		t.Fatalf("Failed to create rotating filter: %v", err)
+	}
+
+	for _, item := range []string{"a", "b", "c", "d", "e"} {
+		filter.Add(item)
+	}
+
+	if filter.Exists("a") || filter.Exists("b") {
+		t.Fatalf("Expected the first generation to be rotated out")
+	}
+	for _, item := range []string{"c", "d", "e"} {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist", item)
+		}
+	}
+	if got := filter.GetStatistics().AddedItems; got != 5 {
+		t.Fatalf("Expected 5 added items, got %d", got)
+	}
+}
+
+func TestRotatingInvalidParameters(t *testing.T) {
+	if _, err := NewRotating(rotatingParams(3)); err == nil {
+		t.Fatalf("Expected an error without a rotation schedule")
+	}
+
+	params := rotatingParams(1)
+	params.RotationItems = 10
+	if _, err := NewRotating(params); err == nil {
+		t.Fatalf("Expected an error for a single generation")
+	}
+}
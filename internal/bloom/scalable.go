@@ -0,0 +1,449 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultGrowthFactor is the capacity multiplier of each new layer of a
+	// scalable filter when Parameters.GrowthFactor is unset
+	DefaultGrowthFactor = 2
+	// DefaultTighteningRatio is the false positive rate multiplier of each
+	// new layer of a scalable filter when Parameters.TighteningRatio is unset
+	DefaultTighteningRatio = 0.85
+	// ScalableFillRatio is the fill ratio at which a layer of a scalable
+	// filter is full. An optimally sized layer reaches it at its capacity.
+	ScalableFillRatio = 0.5
+)
+
+// LayerStatistics describes one layer of a scalable filter
+type LayerStatistics struct {
+	// Size of the layer in bits
+	Size uint64 `json:"size"`
+	// Number of hash functions of the layer
+	NumHashFunctions uint8 `json:"num_hash_functions"`
+	// Number of items the layer was sized for
+	Capacity uint64 `json:"capacity"`
+	// False positive rate the layer was sized for
+	FalsePositiveRate float64 `json:"false_positive_rate"`
+	// Number of items added to the layer
+	AddedItems uint64 `json:"added_items"`
+	// Fraction of the bits of the layer that are set
+	FillRatio float64 `json:"fill_ratio"`
+}
+
+// ScalableBloomFilter is a bloom filter that grows past its estimated
+// capacity (Almeida et al., "Scalable Bloom Filters"). Items are added to the
+// newest layer; once it is half full a new layer with GrowthFactor times the
+// capacity and TighteningRatio times the false positive rate is chained. The
+// error rates form a geometric series, so the compounded false positive rate
+// stays below Parameters.FalsePositiveRate however many items are added.
+type ScalableBloomFilter struct {
+	// parameters for the scalable filter, Size is the size of the first layer
+	params Parameters
+
+	// statistics for the scalable filter
+	stats Statistics
+
+	// layers of the filter, oldest first
+	layers []*BloomFilter
+
+	// options every layer is created with
+	opts []Option
+
+	// check before a new layer is allocated, nil to grow without bound
+	growth func(bytes uint64) error
+
+	// mutex for concurrent access
+	mu *sync.RWMutex
+}
+
+// NewScalable Creates a new ScalableBloomFilter whose first layer holds
+// params.Capacity items
+// parameters:
+//
+//	params	: parameters of the scalable filter
+//	opts	: optional behaviour, e.g. WithKey
+//
+// returns:
+//
+//	*ScalableBloomFilter	: pointer to the ScalableBloomFilter struct
+//	error					: error if the parameters are invalid
+func NewScalable(params Parameters, opts ...Option) (*ScalableBloomFilter, error) {
+	if params.Capacity == 0 {
+		return nil, errors.New("bloom: scalable filter needs a capacity")
+	}
+	if params.FalsePositiveRate <= 0 || params.FalsePositiveRate >= 1 {
+		return nil, fmt.Errorf("bloom: false positive rate %v must be between 0 and 1", params.FalsePositiveRate)
+	}
+	if params.GrowthFactor == 0 {
+		params.GrowthFactor = DefaultGrowthFactor
+	}
+	if params.TighteningRatio == 0 {
+		params.TighteningRatio = DefaultTighteningRatio
+	}
+	if params.TighteningRatio <= 0 || params.TighteningRatio >= 1 {
+		return nil, fmt.Errorf("bloom: tightening ratio %v must be between 0 and 1", params.TighteningRatio)
+	}
+	if _, err := NewHasher(params.HashAlgorithm, params.Seed); err != nil {
+		return nil, err
+	}
+	if params.HashAlgorithm == "" {
+		params.HashAlgorithm = HashMurmur3
+	}
+	params.Type = TypeScalable
+
+	s := &ScalableBloomFilter{
+		params: params,
+		opts:   opts,
+		growth: buildOptions(opts).growth,
+		mu:     &sync.RWMutex{},
+	}
+	s.grow()
+	s.params.Size = s.layers[0].params.Size
+	s.params.NumHashFunctions = s.layers[0].params.NumHashFunctions
+	return s, nil
+}
+
+// Add Adds an item to the newest layer of the scalable filter, chaining a
+// new layer once it is full. Items that already exist are not added again,
+// so duplicates do not use up capacity. If the growth check refuses a new
+// layer the item goes into the full one, raising the false positive rate
+// rather than the memory; Insert reports the refusal instead.
+// parameters:
+//
+//	item	: item to add to the scalable filter
+//
+// returns:
+//
+//	none
+func (s *ScalableBloomFilter) Add(item string) {
+	_ = s.makeRoom()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(item)
+}
+
+// Insert Adds an item to the scalable filter, see Add
+// parameters:
+//
+//	item	: item to add to the scalable filter
+//
+// returns:
+//
+//	error	: error of the growth check if the newest layer is full and a
+//			  new one is refused, the item is not added
+func (s *ScalableBloomFilter) Insert(item string) error {
+	if err := s.makeRoom(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(item)
+	return nil
+}
+
+// AddMany Adds a batch of items to the scalable filter under a single lock
+// parameters:
+//
+//	items	: items to add to the scalable filter
+//
+// returns:
+//
+//	none
+func (s *ScalableBloomFilter) AddMany(items []string) {
+	s.addMany(items, true)
+}
+
+// InsertMany Adds a batch of items to the scalable filter under a single lock
+// per layer, see AddMany. It stops at the first item a new layer is refused
+// for.
+// parameters:
+//
+//	items	: items to add to the scalable filter
+//
+// returns:
+//
+//	int		: number of items added, from the start of the batch
+//	error	: error of the growth check if a new layer is refused
+func (s *ScalableBloomFilter) InsertMany(items []string) (int, error) {
+	return s.addMany(items, false)
+}
+
+// addMany Adds items until the newest layer is full and makes room outside
+// the lock. If a new layer is refused it degrades into the full layer, or
+// stops and returns the error.
+func (s *ScalableBloomFilter) addMany(items []string, degrade bool) (int, error) {
+	added := 0
+	for added < len(items) {
+		err := s.makeRoom()
+		if err != nil && !degrade {
+			return added, err
+		}
+		refused := err != nil
+
+		s.mu.Lock()
+		for added < len(items) && (refused || s.growth == nil || !s.full()) {
+			s.add(items[added])
+			added++
+		}
+		s.mu.Unlock()
+	}
+	return added, nil
+}
+
+// ExistsMany Checks a batch of items in the scalable filter under a single lock
+// parameters:
+//
+//	items	: items to check in the scalable filter
+//
+// returns:
+//
+//	[]bool	: for every item, true if it is in the scalable filter, in the order of items
+func (s *ScalableBloomFilter) ExistsMany(items []string) []bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	atomic.AddUint64(&s.stats.CheckedItems, uint64(len(items)))
+	exists := make([]bool, len(items))
+	for i, item := range items {
+		exists[i] = s.existsHashed(s.layers[0].doHash(item))
+	}
+	return exists
+}
+
+// AddIfAbsent Adds an item to the scalable filter and reports whether it was
+// already present
+// parameters:
+//
+//	item	: item to add to the scalable filter
+//
+// returns:
+//
+//	bool	: true if the item was already in the scalable filter, false otherwise
+func (s *ScalableBloomFilter) AddIfAbsent(item string) bool {
+	_ = s.makeRoom()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.CheckedItems++
+	return s.add(item)
+}
+
+// add Adds an item to the newest layer unless a layer already reports it,
+// and reports whether one did
+func (s *ScalableBloomFilter) add(item string) bool {
+	s.stats.AddedItems++
+	h1, h2 := s.layers[0].doHash(item)
+	if s.existsHashed(h1, h2) {
+		return true
+	}
+
+	last := s.layers[len(s.layers)-1]
+	last.added.Add(1)
+	last.addHashed(h1, h2)
+	// with a growth check the next layer is chained by makeRoom instead
+	if s.growth == nil && s.full() {
+		s.grow()
+	}
+	return false
+}
+
+// full Reports whether the newest layer is full, the caller holds the lock
+func (s *ScalableBloomFilter) full() bool {
+	return s.layers[len(s.layers)-1].FillRatio() >= ScalableFillRatio
+}
+
+// makeRoom Chains a new layer if the newest one is full and the growth
+// check allows it. The check is made without the lock, so it may inspect
+// the filter.
+func (s *ScalableBloomFilter) makeRoom() error {
+	if s.growth == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	full, n := s.full(), len(s.layers)
+	s.mu.RUnlock()
+	if !full {
+		return nil
+	}
+
+	params := layerParameters(s.params, n)
+	if err := s.growth(denseLen(params.Size) + stripeBytes); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// another caller may have chained it in the meantime
+	if len(s.layers) == n {
+		s.grow()
+	}
+	return nil
+}
+
+// Exists Checks if an item is in any layer of the scalable filter
+// parameters:
+//
+//	item	: item to check in the scalable filter
+//
+// returns:
+//
+//	bool	: true if the item is in the scalable filter, false otherwise
+func (s *ScalableBloomFilter) Exists(item string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	atomic.AddUint64(&s.stats.CheckedItems, 1)
+	return s.existsHashed(s.layers[0].doHash(item))
+}
+
+// AddBytes Adds a binary item to the scalable filter, see BytesFilter
+// parameters:
+//
+//	item	: item to add to the scalable filter
+//
+// returns:
+//
+//	none
+func (s *ScalableBloomFilter) AddBytes(item []byte) {
+	s.Add(bytesString(item))
+}
+
+// ExistsBytes Checks if a binary item is in the scalable filter, see BytesFilter
+// parameters:
+//
+//	item	: item to check in the scalable filter
+//
+// returns:
+//
+//	bool	: true if the item is in the scalable filter, false otherwise
+func (s *ScalableBloomFilter) ExistsBytes(item []byte) bool {
+	return s.Exists(bytesString(item))
+}
+
+// Clear Clears the scalable filter, dropping every layer but the first
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	none
+func (s *ScalableBloomFilter) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.layers = s.layers[:1]
+	first := s.layers[0]
+	first.Clear()
+	first.added.Store(0)
+}
+
+func (s *ScalableBloomFilter) GetParameters() Parameters {
+	return s.params
+}
+
+func (s *ScalableBloomFilter) GetStatistics() Statistics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := Statistics{
+		AddedItems:   s.stats.AddedItems,
+		CheckedItems: atomic.LoadUint64(&s.stats.CheckedItems),
+		Layers:       make([]LayerStatistics, 0, len(s.layers)),
+	}
+	for _, layer := range s.layers {
+		stats.addEstimates(layer.GetStatistics())
+		stats.Layers = append(stats.Layers, LayerStatistics{
+			Size:              layer.params.Size,
+			NumHashFunctions:  layer.params.NumHashFunctions,
+			Capacity:          layer.params.Capacity,
+			FalsePositiveRate: layer.params.FalsePositiveRate,
+			AddedItems:        layer.added.Load(),
+			FillRatio:         layer.FillRatio(),
+		})
+	}
+
+	return stats
+}
+
+// SizeInBytes Returns the memory held by every layer of the scalable filter, it grows
+// with the filter
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	uint64	: size of the scalable filter in bytes
+func (s *ScalableBloomFilter) SizeInBytes() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var size uint64
+	for _, layer := range s.layers {
+		size += layer.SizeInBytes()
+	}
+	return size
+}
+
+func (s *ScalableBloomFilter) String() string {
+	return fmt.Sprintf("ScalableBloomFilter{capacity: %d, falsePositiveRate: %v, layers: %d}", s.params.Capacity, s.params.FalsePositiveRate, len(s.layers))
+}
+
+// existsHashed Tests a hashed item against every layer, newest first since
+// it holds most of the items
+func (s *ScalableBloomFilter) existsHashed(h1, h2 uint64) bool {
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		if s.layers[i].existsHashed(h1, h2) {
+			return true
+		}
+	}
+	return false
+}
+
+// grow Chains a new layer, the caller holds the write lock. Layer i holds
+// Capacity*GrowthFactor^i items at FalsePositiveRate*(1-r)*r^i, whose sum
+// over all layers is bounded by FalsePositiveRate.
+func (s *ScalableBloomFilter) grow() {
+	s.layers = append(s.layers, New(layerParameters(s.params, len(s.layers)), s.opts...))
+}
+
+// layerParameters Returns the parameters of the i-th layer of a scalable
+// filter
+func layerParameters(params Parameters, i int) Parameters {
+	r := params.TighteningRatio
+	capacity := float64(params.Capacity) * math.Pow(float64(params.GrowthFactor), float64(i))
+	fpr := params.FalsePositiveRate * (1 - r) * math.Pow(r, float64(i))
+
+	layer := CalculateOptimalParameters(int(math.Ceil(capacity)), fpr)
+	layer.Seed = params.Seed
+	layer.HashAlgorithm = params.HashAlgorithm
+	return layer
+}
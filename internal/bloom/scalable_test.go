@@ -0,0 +1,176 @@
+package bloom
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func newScalableFilter(t *testing.T, n int, p float64) *ScalableBloomFilter {
+	t.Helper()
+	filter, err := NewScalable(CalculateOptimalParameters(n, p))
+	if err != nil {
+		t.Fatalf("Failed to create scalable filter: %v", err)
+	}
+	return filter
+}
+
+func TestScalableGrows(t *testing.T) {
+	capacity := 1000
+	items := test.GenerateStringsOfLength(12, 10*capacity)
+	filter := newScalableFilter(t, capacity, 0.01)
+
+	if got := len(filter.GetStatistics().Layers); got != 1 {
+		t.Fatalf("Expected 1 layer, got %d", got)
+	}
+
+	for _, item := range items {
+		filter.Add(item)
+	}
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+
+	stats := filter.GetStatistics()
+	if len(stats.Layers) < 3 {
+		t.Fatalf("Expected the filter to grow to at least 3 layers, got %d", len(stats.Layers))
+	}
+	for i, layer := range stats.Layers[:len(stats.Layers)-1] {
+		if layer.FillRatio < ScalableFillRatio {
+			t.Errorf("Layer %d: expected a full layer, fill ratio %.3f", i, layer.FillRatio)
+		}
+		next := stats.Layers[i+1]
+		if next.Capacity <= layer.Capacity || next.FalsePositiveRate >= layer.FalsePositiveRate {
+			t.Errorf("Layer %d: expected growing capacity and tightening error rate, got %+v then %+v", i, layer, next)
+		}
+	}
+}
+
+func TestScalableGrowthCheck(t *testing.T) {
+	capacity := 1000
+	items := test.GenerateStringsOfLength(12, 10*capacity)
+	errFull := errors.New("no room")
+
+	var requested []uint64
+	allowed := 2
+	filter, err := NewScalable(CalculateOptimalParameters(capacity, 0.01), WithGrowth(func(bytes uint64) error {
+		if len(requested) == allowed {
+			return errFull
+		}
+		requested = append(requested, bytes)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create scalable filter: %v", err)
+	}
+
+	filter.AddMany(items[:5*capacity])
+	var refused int
+	for _, item := range items[5*capacity:] {
+		if err := filter.Insert(item); err != nil {
+			if !errors.Is(err, errFull) {
+				t.Fatalf("Expected the error of the growth check, got %v", err)
+			}
+			refused++
+		}
+	}
+	if added, err := filter.InsertMany(items); !errors.Is(err, errFull) || added != 0 {
+		t.Fatalf("Expected a batch to be refused by the growth check, got %d added and %v", added, err)
+	}
+
+	stats := filter.GetStatistics()
+	if len(stats.Layers) != allowed+1 || refused == 0 {
+		t.Fatalf("Expected %d layers and refused inserts, got %d layers and %d refused", allowed+1, len(stats.Layers), refused)
+	}
+	for i, bytes := range requested {
+		if layer := stats.Layers[i+1]; bytes != denseLen(layer.Size)+stripeBytes {
+			t.Errorf("Layer %d: expected the check to be given its %d bytes, got %d", i+1, denseLen(layer.Size)+stripeBytes, bytes)
+		}
+	}
+
+	// a refused layer leaves added items in the full one, never losing them
+	filter.Add(items[len(items)-1])
+	filter.AddMany(items)
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+	if got := len(filter.GetStatistics().Layers); got != allowed+1 {
+		t.Fatalf("Expected no layer past the growth check, got %d", got)
+	}
+}
+
+func TestScalableFalsePositiveRate(t *testing.T) {
+	capacity, p := 1000, 0.01
+	filter := newScalableFilter(t, capacity, p)
+
+	// 20x the estimated capacity would push a fixed filter far above p
+	for _, item := range test.GenerateStringsOfLength(12, 20*capacity) {
+		filter.Add(item)
+	}
+
+	testN := 50_000
+	falsePositives := 0
+	for _, item := range test.GenerateStringsOfLength(10, testN) {
+		if filter.Exists(item) {
+			falsePositives++
+		}
+	}
+
+	// allow for sampling noise on top of the bound
+	if rate := float64(falsePositives) / float64(testN); rate > p*1.5 {
+		t.Fatalf("Expected false positive rate below %v, got %v", p, rate)
+	}
+}
+
+func TestScalableSkipsDuplicates(t *testing.T) {
+	filter := newScalableFilter(t, 100, 0.01)
+
+	for range 1000 {
+		filter.Add("apple")
+	}
+
+	stats := filter.GetStatistics()
+	if len(stats.Layers) != 1 || stats.Layers[0].AddedItems != 1 {
+		t.Fatalf("Expected duplicates to use no capacity, got %+v", stats.Layers)
+	}
+	if stats.AddedItems != 1000 {
+		t.Fatalf("Expected 1000 added items, got %d", stats.AddedItems)
+	}
+}
+
+func TestScalableClear(t *testing.T) {
+	filter := newScalableFilter(t, 100, 0.01)
+	items := test.GenerateStringsOfLength(10, 1000)
+	for _, item := range items {
+		filter.Add(item)
+	}
+
+	filter.Clear()
+
+	stats := filter.GetStatistics()
+	if len(stats.Layers) != 1 || stats.Layers[0].FillRatio != 0 {
+		t.Fatalf("Expected a single empty layer, got %+v", stats.Layers)
+	}
+	if filter.Exists(items[0]) {
+		t.Fatalf("Expected item %s to be cleared", items[0])
+	}
+}
+
+func TestScalableInvalidParameters(t *testing.T) {
+	params := CalculateOptimalParameters(100, 0.01)
+	params.TighteningRatio = 1.5
+	if _, err := NewScalable(params); err == nil {
+		t.Fatalf("Expected an error for a tightening ratio above 1")
+	}
+
+	params = CalculateOptimalParameters(100, 0.01)
+	params.Capacity = 0
+	if _, err := NewScalable(params); err == nil {
+		t.Fatalf("Expected an error for a missing capacity")
+	}
+}
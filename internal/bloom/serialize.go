@@ -0,0 +1,442 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dchest/siphash"
+)
+
+// Serialized filters use the following layout, all integers little-endian:
+//
+//	offset	size	field
+//	0		4		magic "BLMF"
+//	4		1		format version, currently 2
+//	5		1		filter type, 1 = standard
+//	6		1		hash algorithm, 1 = murmur3_128, 2 = xxhash64, 3 = fnv1a, 4 = siphash
+//	7		1		payload encoding, 0 = dense, 1 = sparse
+//	8		8		size in bits
+//	16		1		number of hash functions
+//	17		7		reserved, zero
+//	24		8		false positive rate, IEEE 754
+//	32		8		capacity
+//	40		8		seed
+//	48		8		key check, 0 for an unkeyed filter
+//	56		8		added items
+//	64		8		checked items
+//	72		8		payload length in bytes
+//	80		n		payload
+//	80+n	4		CRC32C (Castagnoli) of every preceding byte
+//
+// A dense payload holds the bits as little-endian 64-bit words, least
+// significant bit first. A sparse payload Rice codes the positions of the set
+// bits, see sparse.go; writers pick it when it is smaller, which is the case
+// up to a fill ratio of about 1/5. The secret key of a keyed filter is never
+// written; the key check is a keyed hash of a constant that tells whether a
+// reader holds the same key without revealing it.
+const (
+	// formatMagic identifies a serialized filter
+	formatMagic = "BLMF"
+	// FormatVersion is the version of the serialization format written.
+	// Version 1 derived the bit indices differently, its files are refused.
+	FormatVersion = 2
+	// headerSize is the number of bytes before the payload
+	headerSize = 80
+)
+
+// payload encodings of a serialized filter
+const (
+	payloadDense byte = iota
+	payloadSparse
+)
+
+var (
+	// ErrInvalidFormat is returned when reading data that is not a valid
+	// serialized filter
+	ErrInvalidFormat = errors.New("bloom: invalid serialized filter")
+
+	// ErrKeyMismatch is returned when reading a filter that was written
+	// with a different secret key than the reader holds
+	ErrKeyMismatch = errors.New("bloom: filter was written with a different secret key")
+
+	// castagnoli is the CRC32C table of the trailer
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+	// filterTypeCodes and hashAlgorithmCodes are the on-disk codes of the
+	// filter types and hash algorithms, they must never change
+	filterTypeCodes    = map[FilterType]byte{TypeStandard: 1}
+	hashAlgorithmCodes = map[HashAlgorithm]byte{HashMurmur3: 1, HashXXHash64: 2, HashFNV1a: 3, HashSipHash: 4}
+)
+
+// MarshalBinary Serializes the bloom filter, see WriteTo
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	[]byte	: the serialized filter
+//	error	: error if the filter cannot be serialized
+func (b *BloomFilter) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary Replaces the bloom filter with a serialized one, see
+// ReadFrom
+// parameters:
+//
+//	data	: the serialized filter
+//
+// returns:
+//
+//	error	: error if data is not a valid filter or has trailing bytes
+func (b *BloomFilter) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	if _, err := b.ReadFrom(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidFormat, r.Len())
+	}
+	return nil
+}
+
+// WriteTo Streams the bloom filter in the serialization format, with a sparse
+// payload if that is smaller. Items added while it is written may or may not
+// be part of the output.
+// parameters:
+//
+//	w	: writer to serialize the filter to
+//
+// returns:
+//
+//	int64	: number of bytes written
+//	error	: error if the filter cannot be serialized or written
+func (b *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	st := b.state.Load()
+	store, ok := st.bits.(*PackedBitStore)
+	if !ok {
+		return 0, fmt.Errorf("bloom: cannot serialize a %T", st.bits)
+	}
+
+	var header [headerSize]byte
+	copy(header[0:4], formatMagic)
+	header[4] = FormatVersion
+	header[5] = filterTypeCodes[TypeStandard]
+	header[6] = hashAlgorithmCodes[b.params.HashAlgorithm]
+	binary.LittleEndian.PutUint64(header[8:], b.params.Size)
+	header[16] = b.params.NumHashFunctions
+	binary.LittleEndian.PutUint64(header[24:], math.Float64bits(b.params.FalsePositiveRate))
+	binary.LittleEndian.PutUint64(header[32:], b.params.Capacity)
+	binary.LittleEndian.PutUint64(header[40:], b.params.Seed)
+	binary.LittleEndian.PutUint64(header[48:], keyCheck(st.hasher))
+	binary.LittleEndian.PutUint64(header[56:], b.added.Load())
+	binary.LittleEndian.PutUint64(header[64:], b.checked.Load())
+
+	// a lightly filled filter is encoded aside, the dense bits are streamed
+	dense := denseLen(b.params.Size)
+	header[7] = payloadDense
+	payloadLen := dense
+	var sparse []byte
+	if estimate, k := sparseEstimate(b.params.Size, st.setBits.Load()); estimate < dense {
+		if sparse = encodeSparse(store, k, int(dense)); sparse != nil {
+			header[7] = payloadSparse
+			payloadLen = uint64(len(sparse))
+		}
+	}
+	binary.LittleEndian.PutUint64(header[72:], payloadLen)
+
+	crc := crc32.New(castagnoli)
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	written, err := bw.Write(header[:])
+	n := int64(written)
+	if err != nil {
+		return n, err
+	}
+
+	if sparse != nil {
+		written, err = bw.Write(sparse)
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	var word [8]byte
+	for i := 0; sparse == nil && i < len(store.words); i++ {
+		binary.LittleEndian.PutUint64(word[:], atomic.LoadUint64(&store.words[i]))
+		written, err = bw.Write(word[:])
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return n, err
+	}
+
+	var trailer [4]byte
+	binary.LittleEndian.PutUint32(trailer[:], crc.Sum32())
+	written, err = w.Write(trailer[:])
+	return n + int64(written), err
+}
+
+// ReadFrom Replaces the bloom filter with one read in the serialization
+// format. A keyed filter keeps its secret key, which must be the key the
+// filter was written with. ReadFrom must not run concurrently with other
+// methods of the filter; use ReadFilter to load a filter aside instead.
+// parameters:
+//
+//	r	: reader to read the serialized filter from
+//
+// returns:
+//
+//	int64	: number of bytes read
+//	error	: ErrInvalidFormat or ErrKeyMismatch if the filter cannot be read
+func (b *BloomFilter) ReadFrom(r io.Reader) (int64, error) {
+	var key keyedHasher
+	if st := b.state.Load(); st != nil {
+		key, _ = st.hasher.(keyedHasher)
+	}
+
+	return b.readFrom(r, func(inner Hasher) Hasher {
+		if key.inner == nil {
+			return inner
+		}
+		key.inner = inner
+		return key
+	}, maxFootprintBits)
+}
+
+// ReadFilter Reads a new BloomFilter in the serialization format
+// parameters:
+//
+//	r		: reader to read the serialized filter from
+//	opts	: optional behaviour, e.g. WithKey with the key the filter was
+//			  written with
+//
+// returns:
+//
+//	*BloomFilter	: pointer to the BloomFilter struct
+//	error			: ErrInvalidFormat or ErrKeyMismatch if the filter cannot be read
+func ReadFilter(r io.Reader, opts ...Option) (*BloomFilter, error) {
+	o := buildOptions(opts)
+	b := &BloomFilter{}
+	if _, err := b.readFrom(r, func(inner Hasher) Hasher { return newKeyedHasher(inner, o.key) }, o.maxSize); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// PeekParameters Returns the parameters of the serialized filter at the head
+// of r without consuming it, e.g. to check that it fits before reading it
+// parameters:
+//
+//	r	: buffered reader holding a serialized filter
+//
+// returns:
+//
+//	Parameters	: parameters of the serialized filter
+//	error		: ErrInvalidFormat if r does not start with a valid header
+func PeekParameters(r *bufio.Reader) (Parameters, error) {
+	header, err := r.Peek(headerSize)
+	if err != nil {
+		return Parameters{}, fmt.Errorf("%w: reading header: %w", ErrInvalidFormat, err)
+	}
+	params, _, _, _, err := parseHeader(header)
+	return params, err
+}
+
+// ReadExactly Reads a new BloomFilter like ReadFilter and checks that r holds
+// nothing after it, e.g. for an uploaded filter
+// parameters:
+//
+//	r		: reader holding exactly one serialized filter
+//	opts	: optional behaviour, e.g. WithKey with the key the filter was
+//			  written with
+//
+// returns:
+//
+//	*BloomFilter	: pointer to the BloomFilter struct
+//	error			: ErrInvalidFormat or ErrKeyMismatch if the filter cannot be read
+func ReadExactly(r io.Reader, opts ...Option) (*BloomFilter, error) {
+	filter, err := ReadFilter(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra [1]byte
+	if n, _ := io.ReadFull(r, extra[:]); n != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes", ErrInvalidFormat)
+	}
+	return filter, nil
+}
+
+// readFrom Reads a serialized filter of at most maxSize bits, keying its hash
+// family with withKey
+func (b *BloomFilter) readFrom(r io.Reader, withKey func(Hasher) Hasher, maxSize uint64) (int64, error) {
+	crc := crc32.New(castagnoli)
+	tee := io.TeeReader(r, crc)
+
+	var header [headerSize]byte
+	read, err := io.ReadFull(tee, header[:])
+	n := int64(read)
+	if err != nil {
+		return n, fmt.Errorf("%w: reading header: %w", ErrInvalidFormat, err)
+	}
+
+	params, added, checked, check, err := parseHeader(header[:])
+	if err != nil {
+		return n, err
+	}
+	// a sparse payload of a few bytes may announce any size, refuse it
+	// before the bits are allocated
+	if params.Size > maxSize {
+		return n, fmt.Errorf("%w: size of %d bits exceeds %d", ErrInvalidFormat, params.Size, maxSize)
+	}
+
+	inner, err := NewHasher(params.HashAlgorithm, params.Seed)
+	if err != nil {
+		return n, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	hasher := withKey(inner)
+	if keyCheck(hasher) != check {
+		return n, ErrKeyMismatch
+	}
+
+	// buffer the payload as it arrives, so a truncated stream fails before a
+	// filter of the announced size is allocated
+	var payload bytes.Buffer
+	copied, err := io.CopyN(&payload, tee, int64(binary.LittleEndian.Uint64(header[72:])))
+	n += copied
+	if err != nil {
+		return n, fmt.Errorf("%w: reading payload: %w", ErrInvalidFormat, err)
+	}
+
+	sum := crc.Sum32()
+	var trailer [4]byte
+	read, err = io.ReadFull(r, trailer[:])
+	n += int64(read)
+	if err != nil {
+		return n, fmt.Errorf("%w: reading checksum: %w", ErrInvalidFormat, err)
+	}
+	if binary.LittleEndian.Uint32(trailer[:]) != sum {
+		return n, fmt.Errorf("%w: checksum mismatch", ErrInvalidFormat)
+	}
+
+	store := &PackedBitStore{size: params.Size}
+	if header[7] == payloadSparse {
+		if store.words, err = decodeSparse(payload.Bytes(), params.Size); err != nil {
+			return n, err
+		}
+	} else {
+		data := payload.Bytes()
+		store.words = make([]uint64, len(data)/8)
+		for i := range store.words {
+			store.words[i] = binary.LittleEndian.Uint64(data[i*8:])
+		}
+	}
+	if tail := params.Size % wordBits; tail != 0 && store.words[len(store.words)-1]>>tail != 0 {
+		return n, fmt.Errorf("%w: bits set beyond the size", ErrInvalidFormat)
+	}
+
+	st := newBloomState(params, store, hasher)
+	st.setBits.Store(store.count())
+
+	b.params = params
+	if b.mu == nil {
+		b.mu = &sync.Mutex{}
+		b.stripes = make([]sync.Mutex, addStripes)
+	}
+	b.added.Store(added)
+	b.checked.Store(checked)
+	b.state.Store(st)
+	return n, nil
+}
+
+// parseHeader Validates a header and returns the parameters, statistics and
+// key check it holds
+func parseHeader(header []byte) (Parameters, uint64, uint64, uint64, error) {
+	var params Parameters
+	if string(header[0:4]) != formatMagic {
+		return params, 0, 0, 0, fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, header[0:4])
+	}
+	if header[4] != FormatVersion {
+		return params, 0, 0, 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, header[4])
+	}
+	if header[5] != filterTypeCodes[TypeStandard] {
+		return params, 0, 0, 0, fmt.Errorf("%w: unsupported filter type %d", ErrInvalidFormat, header[5])
+	}
+	if header[7] != payloadDense && header[7] != payloadSparse {
+		return params, 0, 0, 0, fmt.Errorf("%w: unsupported payload encoding %d", ErrInvalidFormat, header[7])
+	}
+
+	for alg, code := range hashAlgorithmCodes {
+		if code == header[6] {
+			params.HashAlgorithm = alg
+		}
+	}
+	if params.HashAlgorithm == "" {
+		return params, 0, 0, 0, fmt.Errorf("%w: unknown hash algorithm %d", ErrInvalidFormat, header[6])
+	}
+
+	params.Type = TypeStandard
+	params.Size = binary.LittleEndian.Uint64(header[8:])
+	params.NumHashFunctions = header[16]
+	params.FalsePositiveRate = math.Float64frombits(binary.LittleEndian.Uint64(header[24:]))
+	params.Capacity = binary.LittleEndian.Uint64(header[32:])
+	params.Seed = binary.LittleEndian.Uint64(header[40:])
+	if params.Size == 0 || params.Size > maxFootprintBits || params.NumHashFunctions == 0 {
+		return params, 0, 0, 0, fmt.Errorf("%w: invalid size %d or hash functions %d", ErrInvalidFormat, params.Size, params.NumHashFunctions)
+	}
+	// a sparse payload is only written when it is smaller than a dense one
+	length, dense := binary.LittleEndian.Uint64(header[72:]), denseLen(params.Size)
+	if length != dense && (header[7] != payloadSparse || length > dense) {
+		return params, 0, 0, 0, fmt.Errorf("%w: payload of %d bytes for %d bits", ErrInvalidFormat, length, params.Size)
+	}
+
+	added := binary.LittleEndian.Uint64(header[56:])
+	checked := binary.LittleEndian.Uint64(header[64:])
+	return params, added, checked, binary.LittleEndian.Uint64(header[48:]), nil
+}
+
+// denseLen Returns the length in bytes of the dense payload of size bits
+func denseLen(size uint64) uint64 {
+	return (size + wordBits - 1) / wordBits * 8
+}
+
+// keyCheck Returns a keyed hash of a constant that identifies the secret key
+// of a hasher without revealing it, 0 for an unkeyed hasher
+func keyCheck(h Hasher) uint64 {
+	keyed, ok := h.(keyedHasher)
+	if !ok {
+		return 0
+	}
+	return siphash.Hash(keyed.k0, keyed.k1, []byte("bloomservice key check")) | 1
+}
@@ -0,0 +1,377 @@
+package bloom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenItems are the items of the golden filters
+var goldenItems = []string{"apple", "banana", "cherry"}
+
+// goldenFilter builds the lightly filled filter stored in the sparse golden
+// files, filled adds enough items to store it dense
+func goldenFilter(filled bool, opts ...Option) *BloomFilter {
+	params := CalculateOptimalParameters(100, 0.01)
+	params.Seed = 42
+	filter := New(params, opts...)
+	filter.AddMany(goldenItems)
+	if filled {
+		for i := range 100 {
+			filter.Add(fmt.Sprintf("item-%d", i))
+		}
+	}
+	filter.Exists("apple")
+	return filter
+}
+
+func TestMarshalBinaryGolden(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		filled   bool
+		encoding byte
+		opts     []Option
+	}{
+		{"sparse", "standard_sparse_v2.bin", false, payloadSparse, nil},
+		{"keyed sparse", "standard_keyed_sparse_v2.bin", false, payloadSparse, []Option{WithKey([]byte("secret"))}},
+		{"dense", "standard_dense_v2.bin", true, payloadDense, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			original := goldenFilter(tc.filled, tc.opts...)
+			data, err := original.MarshalBinary()
+			if err != nil {
+				t.Fatalf("Failed to marshal filter: %v", err)
+			}
+			if data[7] != tc.encoding {
+				t.Fatalf("Expected payload encoding %d, got %d", tc.encoding, data[7])
+			}
+
+			path := filepath.Join("testdata", tc.file)
+			if *update {
+				if err := os.WriteFile(path, data, 0o644); err != nil {
+					t.Fatalf("Failed to update golden file: %v", err)
+				}
+			}
+			golden, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read golden file: %v", err)
+			}
+			if !bytes.Equal(data, golden) {
+				t.Fatalf("Serialized filter differs from %s, run go test -update if the format changed on purpose", path)
+			}
+
+			filter, err := ReadFilter(bytes.NewReader(golden), tc.opts...)
+			if err != nil {
+				t.Fatalf("Failed to read golden file: %v", err)
+			}
+			if !reflect.DeepEqual(filter.GetStatistics(), original.GetStatistics()) {
+				t.Fatalf("Expected statistics %+v, got %+v", original.GetStatistics(), filter.GetStatistics())
+			}
+			for _, item := range goldenItems {
+				if !filter.Exists(item) {
+					t.Fatalf("Expected item %s to exist in the golden filter", item)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFilterVersion1Golden(t *testing.T) {
+	// files written before the bit indices were derived with an odd step
+	// would load but miss their items, so they must be refused
+	files := []string{"standard_v1.bin", "standard_keyed_v1.bin", "standard_sparse_v1.bin", "standard_keyed_sparse_v1.bin", "standard_dense_v1.bin"}
+	for _, file := range files {
+		golden, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatalf("Failed to read golden file: %v", err)
+		}
+		if golden[4] != 1 {
+			t.Fatalf("Expected %s to be written in version 1, got %d", file, golden[4])
+		}
+		if _, err := ReadFilter(bytes.NewReader(golden), WithKey([]byte("secret"))); !errors.Is(err, ErrInvalidFormat) {
+			t.Fatalf("Expected ErrInvalidFormat reading %s, got %v", file, err)
+		}
+	}
+}
+
+func TestMarshalBinaryRoundTrip(t *testing.T) {
+	for _, alg := range HashAlgorithms {
+		params := CalculateOptimalParameters(1000, 0.01)
+		params.HashAlgorithm = alg
+		params.Seed = 7
+		filter := New(params)
+		items := test.GenerateStringsOfLength(10, 500)
+		filter.AddMany(items)
+
+		data, err := filter.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal %s filter: %v", alg, err)
+		}
+
+		var restored BloomFilter
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to unmarshal %s filter: %v", alg, err)
+		}
+		if restored.GetParameters() != filter.GetParameters() {
+			t.Fatalf("Expected parameters %+v, got %+v", filter.GetParameters(), restored.GetParameters())
+		}
+		if restored.GetStatistics().SetBits != filter.GetStatistics().SetBits {
+			t.Fatalf("Expected %d set bits, got %d", filter.GetStatistics().SetBits, restored.GetStatistics().SetBits)
+		}
+		for _, item := range items {
+			if !restored.Exists(item) {
+				t.Fatalf("Expected item %s to exist in the restored %s filter", item, alg)
+			}
+		}
+
+		// the restored filter serializes to the same header and bits, only the
+		// checked items and the checksum differ after the lookups above
+		again, _ := restored.MarshalBinary()
+		if !bytes.Equal(data[:64], again[:64]) || !bytes.Equal(data[headerSize:len(data)-4], again[headerSize:len(again)-4]) {
+			t.Fatalf("Expected a re-serialized %s filter to keep its header and bits", alg)
+		}
+	}
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	filter := goldenFilter(false)
+	var buf bytes.Buffer
+	written, err := filter.WriteTo(&buf)
+	if err != nil || written != int64(buf.Len()) {
+		t.Fatalf("Expected %d bytes written, got %d (%v)", buf.Len(), written, err)
+	}
+
+	// reading into a keyed filter needs the key it was written with
+	keyed := New(CalculateOptimalParameters(10, 0.01), WithKey([]byte("secret")))
+	if _, err := keyed.ReadFrom(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("Expected ErrKeyMismatch, got %v", err)
+	}
+
+	restored := New(CalculateOptimalParameters(10, 0.01))
+	read, err := restored.ReadFrom(&buf)
+	if err != nil || read != written {
+		t.Fatalf("Expected %d bytes read, got %d (%v)", written, read, err)
+	}
+	if !restored.Exists("banana") || restored.GetParameters().Size != filter.GetParameters().Size {
+		t.Fatalf("Expected the filter to be replaced by the serialized one")
+	}
+}
+
+func TestPeekParameters(t *testing.T) {
+	filter := goldenFilter(false)
+	data, _ := filter.MarshalBinary()
+
+	r := bufio.NewReader(bytes.NewReader(data))
+	params, err := PeekParameters(r)
+	if err != nil {
+		t.Fatalf("Failed to peek parameters: %v", err)
+	}
+	if params != filter.GetParameters() {
+		t.Fatalf("Expected parameters %+v, got %+v", filter.GetParameters(), params)
+	}
+
+	// peeking consumes nothing
+	if _, err := ReadExactly(r); err != nil {
+		t.Fatalf("Failed to read the peeked filter: %v", err)
+	}
+
+	if _, err := PeekParameters(bufio.NewReader(bytes.NewReader(data[:headerSize-1]))); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("Expected ErrInvalidFormat for a truncated header, got %v", err)
+	}
+}
+
+func TestReadFilterKey(t *testing.T) {
+	data, _ := goldenFilter(false, WithKey([]byte("secret"))).MarshalBinary()
+
+	if _, err := ReadFilter(bytes.NewReader(data)); !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("Expected reading without the key to fail, got %v", err)
+	}
+	if _, err := ReadFilter(bytes.NewReader(data), WithKey([]byte("other"))); !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("Expected reading with another key to fail, got %v", err)
+	}
+
+	// the key itself is never written
+	if bytes.Contains(data, []byte("secret")) {
+		t.Fatalf("Expected the secret key to be left out of the serialized filter")
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	data, _ := goldenFilter(false).MarshalBinary()
+	corrupt := func(offset int, value byte) []byte {
+		c := bytes.Clone(data)
+		c[offset] = value
+		return c
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"bad magic", corrupt(0, 'X')},
+		{"unknown version", corrupt(4, FormatVersion+1)},
+		{"unknown type", corrupt(5, 9)},
+		{"unknown hash", corrupt(6, 9)},
+		{"unknown payload encoding", corrupt(7, 9)},
+		{"wrong payload length", corrupt(72, 1)},
+		{"flipped payload bit", corrupt(headerSize, data[headerSize]^1)},
+		{"dense length for a sparse payload", corrupt(72, data[72]+8)},
+		{"flipped checksum", corrupt(len(data)-1, data[len(data)-1]^1)},
+		{"truncated", data[:len(data)-10]},
+		{"trailing bytes", append(bytes.Clone(data), 0)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var filter BloomFilter
+			if err := filter.UnmarshalBinary(tc.data); !errors.Is(err, ErrInvalidFormat) {
+				t.Fatalf("Expected ErrInvalidFormat, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadFilterOversized(t *testing.T) {
+	data, _ := goldenFilter(false).MarshalBinary()
+	resize := func(size uint64) []byte {
+		c := bytes.Clone(data)
+		binary.LittleEndian.PutUint64(c[8:], size)
+		binary.LittleEndian.PutUint32(c[len(c)-4:], crc32.Checksum(c[:len(c)-4], castagnoli))
+		return c
+	}
+
+	// a sparse payload of a few bytes announcing a huge filter must be
+	// refused before the bits are allocated
+	if _, err := ReadFilter(bytes.NewReader(resize(1 << 62))); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("Expected ErrInvalidFormat for a size beyond any filter, got %v", err)
+	}
+	if _, err := ReadExactly(bytes.NewReader(resize(1<<40)), WithMaxSize(1<<20)); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("Expected ErrInvalidFormat for a size beyond the expected one, got %v", err)
+	}
+	if _, err := PeekParameters(bufio.NewReader(bytes.NewReader(resize(1 << 62)))); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("Expected PeekParameters to refuse a size beyond any filter, got %v", err)
+	}
+
+	params := goldenFilter(false).GetParameters()
+	if _, err := ReadFilter(bytes.NewReader(data), WithMaxSize(params.Size)); err != nil {
+		t.Fatalf("Expected a filter of the expected size to be read, got %v", err)
+	}
+}
+
+func TestSparseRoundTrip(t *testing.T) {
+	stores := map[string]*PackedBitStore{"empty": NewPackedBitStore(1000)}
+
+	edges := NewPackedBitStore(130)
+	for _, i := range []uint64{0, 63, 64, 65, 129} {
+		edges.Set(i)
+	}
+	stores["edges"] = edges
+
+	for _, fill := range []float64{0.0001, 0.001, 0.01, 0.1, 0.2} {
+		store := NewPackedBitStore(1_000_003)
+		for i := range store.Len() {
+			if float64(mix64(i)%100_000)/100_000 < fill {
+				store.Set(i)
+			}
+		}
+		stores[fmt.Sprintf("fill %g", fill)] = store
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			_, k := sparseEstimate(store.Len(), store.count())
+			data := encodeSparse(store, k, int(denseLen(store.Len())))
+			if data == nil {
+				t.Fatalf("Expected %d set bits of %d to fit a sparse payload", store.count(), store.Len())
+			}
+
+			words, err := decodeSparse(data, store.Len())
+			if err != nil {
+				t.Fatalf("Failed to decode sparse payload: %v", err)
+			}
+			if !slices.Equal(words, store.words) {
+				t.Fatalf("Expected the decoded bits to match the encoded ones")
+			}
+		})
+	}
+}
+
+func TestSparseChosenByFillRatio(t *testing.T) {
+	params := CalculateOptimalParameters(100_000, 0.01)
+	dense := int(headerSize + denseLen(params.Size) + 4)
+
+	tests := []struct {
+		items  int
+		sparse bool
+	}{
+		{0, true},
+		{1000, true},
+		{10_000, true},
+		{100_000, false},
+	}
+	for _, tc := range tests {
+		filter := New(params)
+		filter.AddMany(test.GenerateStringsOfLength(10, tc.items))
+		data, err := filter.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal filter: %v", err)
+		}
+
+		if sparse := data[7] == payloadSparse; sparse != tc.sparse {
+			t.Fatalf("Expected %d items to be sparse=%v, got %d bytes of %d dense", tc.items, tc.sparse, len(data), dense)
+		}
+		if tc.sparse && len(data) >= dense {
+			t.Fatalf("Expected a sparse filter of %d items to be smaller than %d bytes, got %d", tc.items, dense, len(data))
+		}
+
+		restored, err := ReadFilter(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Failed to read filter of %d items: %v", tc.items, err)
+		}
+		if !slices.Equal(restored.state.Load().bits.(*PackedBitStore).words, filter.state.Load().bits.(*PackedBitStore).words) {
+			t.Fatalf("Expected the bits of %d items to round trip", tc.items)
+		}
+	}
+}
+
+func TestDecodeSparseInvalid(t *testing.T) {
+	store := NewPackedBitStore(1000)
+	store.Set(10)
+	store.Set(500)
+	_, k := sparseEstimate(1000, 2)
+	data := encodeSparse(store, k, 1000)
+
+	tests := []struct {
+		name string
+		data []byte
+		size uint64
+	}{
+		{"empty", nil, 1000},
+		{"more set bits than the size", append([]byte{0xff, 0x0f}, data[1:]...), 1000},
+		{"bad Rice parameter", append([]byte{data[0], 64}, data[2:]...), 1000},
+		{"truncated", data[:len(data)-1], 1000},
+		{"trailing bytes", append(bytes.Clone(data), 0), 1000},
+		{"bit beyond the size", data, 400},
+		{"size beyond any filter", data, 1 << 62},
+	}
+	for _, tc := range tests {
+		if _, err := decodeSparse(tc.data, tc.size); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("%s: expected ErrInvalidFormat, got %v", tc.name, err)
+		}
+	}
+}
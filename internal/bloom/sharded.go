@@ -0,0 +1,394 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"fmt"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// DefaultShards is the number of shards of a sharded filter when
+// Parameters.Shards is unset
+const DefaultShards = 16
+
+// ShardStatistics describes one shard of a sharded filter
+type ShardStatistics struct {
+	// Number of items added to the shard
+	AddedItems uint64 `json:"added_items"`
+	// Number of items checked in the shard
+	CheckedItems uint64 `json:"checked_items"`
+	// Fraction of the bits of the shard that are set
+	FillRatio float64 `json:"fill_ratio"`
+}
+
+// cacheLineSize is the size in bytes of a CPU cache line
+const cacheLineSize = 64
+
+// bloomShard is an independently locked slice of the bit space of a
+// sharded filter. Its state is preceded by a full cache line, so however the
+// shards are aligned, the locks and statistics of neighbouring shards never
+// share one; the tail rounds the shard up to whole cache lines.
+type bloomShard struct {
+	_ [cacheLineSize]byte
+	shardState
+	_ [(cacheLineSize - unsafe.Sizeof(shardState{})%cacheLineSize) % cacheLineSize]byte
+}
+
+// shardState is the state of a bloomShard
+type shardState struct {
+	// mutex for concurrent access to the shard
+	mu sync.RWMutex
+
+	// statistics for the shard
+	stats Statistics
+
+	// packed bits of the shard
+	words []uint64
+
+	// number of bits set in the shard
+	setBits uint64
+}
+
+// ShardedBloomFilter is a bloom filter whose bit space is partitioned into
+// independent shards, each behind its own lock. A top-level hash picks the
+// shard of an item and all of its bits fall inside that shard, so writers of
+// different shards never contend.
+type ShardedBloomFilter struct {
+	// parameters for the sharded filter, Size is the total over all shards
+	params Parameters
+
+	// shards of the filter
+	shards []bloomShard
+
+	// size of one shard in bits
+	shardSize uint64
+
+	// hasher the shard and bit indices are derived from
+	hasher Hasher
+}
+
+// NewSharded Creates a new ShardedBloomFilter with params.Shards shards. If
+// params.Capacity is set the shards are sized by CalculateShardedParameters,
+// otherwise params.Size bits are split evenly.
+// parameters:
+//
+//	params	: parameters of the sharded filter
+//	opts	: optional behaviour, e.g. WithKey
+//
+// returns:
+//
+//	*ShardedBloomFilter	: pointer to the ShardedBloomFilter struct
+//	error				: error if the parameters are invalid
+func NewSharded(params Parameters, opts ...Option) (*ShardedBloomFilter, error) {
+	params, shardSize := shardedLayout(params)
+
+	hasher, err := newFilterHasher(params, buildOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if params.HashAlgorithm == "" {
+		params.HashAlgorithm = HashMurmur3
+	}
+
+	shards := make([]bloomShard, params.Shards)
+	for i := range shards {
+		shards[i].words = make([]uint64, (shardSize+wordBits-1)/wordBits)
+	}
+
+	return &ShardedBloomFilter{
+		params:    params,
+		shards:    shards,
+		shardSize: shardSize,
+		hasher:    hasher,
+	}, nil
+}
+
+// shardedLayout Fills in the defaults of the parameters of a sharded filter,
+// resizes it for its capacity and derives the size of a shard
+func shardedLayout(params Parameters) (Parameters, uint64) {
+	if params.Shards == 0 {
+		params.Shards = DefaultShards
+	}
+	if params.Capacity > 0 && params.FalsePositiveRate > 0 && params.FalsePositiveRate < 1 {
+		sized := CalculateShardedParameters(int(params.Capacity), params.FalsePositiveRate, int(params.Shards))
+		params.Size = sized.Size
+		params.NumHashFunctions = sized.NumHashFunctions
+	}
+
+	numShards := uint64(params.Shards)
+	shardSize := (params.Size + numShards - 1) / numShards
+	params.Type = TypeSharded
+	params.Size = shardSize * numShards
+	return params, shardSize
+}
+
+// shardedBytes Returns the memory held by numShards shards of shardSize bits
+func shardedBytes(numShards, shardSize uint64) uint64 {
+	return numShards * (uint64(unsafe.Sizeof(bloomShard{})) + (shardSize+wordBits-1)/wordBits*8)
+}
+
+// Add Adds an item to its shard of the sharded filter
+// parameters:
+//
+//	item	: item to add to the sharded filter
+//
+// returns:
+//
+//	none
+func (f *ShardedBloomFilter) Add(item string) {
+	shard, h1, h2 := f.doHash(item)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	f.add(shard, h1, h2)
+}
+
+// AddIfAbsent Adds an item to its shard and reports whether it was already
+// present, under the lock of the shard
+// parameters:
+//
+//	item	: item to add to the sharded filter
+//
+// returns:
+//
+//	bool	: true if the item was already in the sharded filter, false otherwise
+func (f *ShardedBloomFilter) AddIfAbsent(item string) bool {
+	shard, h1, h2 := f.doHash(item)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.stats.CheckedItems++
+	return !f.add(shard, h1, h2)
+}
+
+// Exists Checks if an item is in its shard of the sharded filter
+// parameters:
+//
+//	item	: item to check in the sharded filter
+//
+// returns:
+//
+//	bool	: true if the item is in the sharded filter, false otherwise
+func (f *ShardedBloomFilter) Exists(item string) bool {
+	shard, h1, h2 := f.doHash(item)
+
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	atomic.AddUint64(&shard.stats.CheckedItems, 1)
+	return f.exists(shard, h1, h2)
+}
+
+// AddBytes Adds a binary item to the sharded filter, see BytesFilter
+// parameters:
+//
+//	item	: item to add to the sharded filter
+//
+// returns:
+//
+//	none
+func (f *ShardedBloomFilter) AddBytes(item []byte) {
+	f.Add(bytesString(item))
+}
+
+// ExistsBytes Checks if a binary item is in the sharded filter, see BytesFilter
+// parameters:
+//
+//	item	: item to check in the sharded filter
+//
+// returns:
+//
+//	bool	: true if the item is in the sharded filter, false otherwise
+func (f *ShardedBloomFilter) ExistsBytes(item []byte) bool {
+	return f.Exists(bytesString(item))
+}
+
+// AddMany Adds a batch of items to the sharded filter, locking every shard once
+// for all of its items
+// parameters:
+//
+//	items	: items to add to the sharded filter
+//
+// returns:
+//
+//	none
+func (f *ShardedBloomFilter) AddMany(items []string) {
+	hashed := f.group(items)
+	for i := range f.shards {
+		if len(hashed[i]) == 0 {
+			continue
+		}
+		shard := &f.shards[i]
+		shard.mu.Lock()
+		for _, h := range hashed[i] {
+			f.add(shard, h.h1, h.h2)
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// ExistsMany Checks a batch of items in the sharded filter, locking every shard once
+// for all of its items
+// parameters:
+//
+//	items	: items to check in the sharded filter
+//
+// returns:
+//
+//	[]bool	: for every item, true if it is in the sharded filter, in the order of items
+func (f *ShardedBloomFilter) ExistsMany(items []string) []bool {
+	exists := make([]bool, len(items))
+	hashed := f.group(items)
+	for i := range f.shards {
+		if len(hashed[i]) == 0 {
+			continue
+		}
+		shard := &f.shards[i]
+		shard.mu.RLock()
+		atomic.AddUint64(&shard.stats.CheckedItems, uint64(len(hashed[i])))
+		for _, h := range hashed[i] {
+			exists[h.index] = f.exists(shard, h.h1, h.h2)
+		}
+		shard.mu.RUnlock()
+	}
+	return exists
+}
+
+// Clear Clears every shard of the sharded filter
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	none
+func (f *ShardedBloomFilter) Clear() {
+	for i := range f.shards {
+		shard := &f.shards[i]
+		shard.mu.Lock()
+		clear(shard.words)
+		shard.setBits = 0
+		shard.mu.Unlock()
+	}
+}
+
+func (f *ShardedBloomFilter) GetParameters() Parameters {
+	return f.params
+}
+
+func (f *ShardedBloomFilter) GetStatistics() Statistics {
+	k := uint64(f.params.NumHashFunctions)
+	stats := Statistics{Shards: make([]ShardStatistics, len(f.shards))}
+	for i := range f.shards {
+		shard := &f.shards[i]
+		shard.mu.RLock()
+		var part Statistics
+		part.estimate(f.shardSize, k, shard.setBits)
+		stats.Shards[i] = ShardStatistics{
+			AddedItems:   shard.stats.AddedItems,
+			CheckedItems: atomic.LoadUint64(&shard.stats.CheckedItems),
+			FillRatio:    float64(shard.setBits) / float64(f.shardSize),
+		}
+		shard.mu.RUnlock()
+
+		stats.AddedItems += stats.Shards[i].AddedItems
+		stats.CheckedItems += stats.Shards[i].CheckedItems
+		stats.SetBits += part.SetBits
+		stats.EstimatedItems += part.EstimatedItems
+		// a lookup lands in one shard, picked uniformly by the hash
+		stats.EstimatedFalsePositiveRate += part.EstimatedFalsePositiveRate / float64(len(f.shards))
+	}
+	return stats
+}
+
+// SizeInBytes Returns the memory held by the shards of the sharded filter, their
+// bits and locks
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	uint64	: size of the sharded filter in bytes
+func (f *ShardedBloomFilter) SizeInBytes() uint64 {
+	return shardedBytes(uint64(len(f.shards)), f.shardSize)
+}
+
+func (f *ShardedBloomFilter) String() string {
+	return fmt.Sprintf("ShardedBloomFilter{size: %d, shards: %d, hashFunctions: %d}", f.params.Size, len(f.shards), f.params.NumHashFunctions)
+}
+
+// hashedItem is an item of a batch hashed by group
+type hashedItem struct {
+	index  int
+	h1, h2 uint64
+}
+
+// group Hashes a batch of items and groups them by shard
+func (f *ShardedBloomFilter) group(items []string) [][]hashedItem {
+	hashed := make([][]hashedItem, len(f.shards))
+	for i, item := range items {
+		h1, h2 := f.hasher.Sum128(stringBytes(item))
+		idx := f.shardOf(h1, h2)
+		hashed[idx] = append(hashed[idx], hashedItem{index: i, h1: h1, h2: h2})
+	}
+	return hashed
+}
+
+// exists Tests the bits of a hashed item in its locked shard
+func (f *ShardedBloomFilter) exists(shard *bloomShard, h1, h2 uint64) bool {
+	for i := range uint64(f.params.NumHashFunctions) {
+		idx := location(h1, h2, i, f.shardSize)
+		if shard.words[idx/wordBits]&(1<<(idx%wordBits)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// add Sets the bits of a hashed item in its locked shard and reports whether
+// any of them was newly set
+func (f *ShardedBloomFilter) add(shard *bloomShard, h1, h2 uint64) bool {
+	added := false
+	shard.stats.AddedItems++
+	for i := range uint64(f.params.NumHashFunctions) {
+		idx := location(h1, h2, i, f.shardSize)
+		word, mask := &shard.words[idx/wordBits], uint64(1)<<(idx%wordBits)
+		if *word&mask == 0 {
+			*word |= mask
+			shard.setBits++
+			added = true
+		}
+	}
+	return added
+}
+
+// doHash Hashes the input string once and picks its shard
+func (f *ShardedBloomFilter) doHash(input string) (*bloomShard, uint64, uint64) {
+	h1, h2 := f.hasher.Sum128(stringBytes(input))
+	return &f.shards[f.shardOf(h1, h2)], h1, h2
+}
+
+// shardOf Picks the shard of a hashed item from a scrambled copy of the hash,
+// so it is independent of the bit indices inside the shard
+func (f *ShardedBloomFilter) shardOf(h1, h2 uint64) uint64 {
+	idx, _ := bits.Mul64(mix64(h1^h2), uint64(len(f.shards)))
+	return idx
+}
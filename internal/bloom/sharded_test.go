@@ -0,0 +1,189 @@
+package bloom
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"github.com/vinit-chauhan/go-bloomservice/test"
+)
+
+func newShardedFilter(t *testing.T, n int, p float64, shards int) *ShardedBloomFilter {
+	t.Helper()
+	params := CalculateOptimalParameters(n, p)
+	params.Shards = uint16(shards)
+	filter, err := NewSharded(params)
+	if err != nil {
+		t.Fatalf("Failed to create sharded filter: %v", err)
+	}
+	return filter
+}
+
+func TestShardedAddAndExists(t *testing.T) {
+	items := test.GenerateStringsOfLength(10, 1000)
+	filter := newShardedFilter(t, len(items), 0.01, 8)
+
+	for _, item := range items {
+		filter.Add(item)
+	}
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+
+	stats := filter.GetStatistics()
+	if stats.AddedItems != uint64(len(items)) || stats.CheckedItems != uint64(len(items)) {
+		t.Fatalf("Expected %d added and checked items, got %+v", len(items), stats)
+	}
+	if len(stats.Shards) != 8 {
+		t.Fatalf("Expected 8 shard statistics, got %d", len(stats.Shards))
+	}
+	for i, shard := range stats.Shards {
+		if shard.AddedItems == 0 || shard.FillRatio == 0 {
+			t.Fatalf("Expected shard %d to receive items, got %+v", i, shard)
+		}
+	}
+
+	filter.Clear()
+	if filter.Exists(items[0]) {
+		t.Fatalf("Expected item %s to be cleared", items[0])
+	}
+}
+
+func TestShardedDefaults(t *testing.T) {
+	filter, err := NewFilter(Parameters{Type: TypeSharded, Capacity: 1000, FalsePositiveRate: 0.01, Size: 1, NumHashFunctions: 1})
+	if err != nil {
+		t.Fatalf("Failed to create sharded filter: %v", err)
+	}
+
+	params := filter.GetParameters()
+	if params.Shards != DefaultShards || params.Type != TypeSharded {
+		t.Fatalf("Expected %d shards of type %s, got %+v", DefaultShards, TypeSharded, params)
+	}
+	if params.Size%uint64(params.Shards) != 0 {
+		t.Fatalf("Expected the size to be whole shards, got %d bits", params.Size)
+	}
+	// the capacity overrides the explicit size
+	if params.Size < CalculateOptimalParameters(1000, 0.01).Size {
+		t.Fatalf("Expected the filter to be sized for its capacity, got %d bits", params.Size)
+	}
+}
+
+func TestShardPadding(t *testing.T) {
+	if size := unsafe.Sizeof(bloomShard{}); size%cacheLineSize != 0 {
+		t.Fatalf("Expected a shard to fill whole cache lines, got %d bytes", size)
+	}
+
+	// the state of a shard must end on an earlier cache line than the one
+	// the state of the next shard starts on
+	filter := newShardedFilter(t, 1000, 0.01, 16)
+	for i := 1; i < len(filter.shards); i++ {
+		last := uintptr(unsafe.Pointer(&filter.shards[i-1].shardState)) + unsafe.Sizeof(shardState{}) - 1
+		first := uintptr(unsafe.Pointer(&filter.shards[i].shardState))
+		if last/cacheLineSize >= first/cacheLineSize {
+			t.Fatalf("Expected shards %d and %d to share no cache line", i-1, i)
+		}
+	}
+}
+
+// BenchmarkShardLocks locks a neighbouring shard from every goroutine, with
+// and without the padding of bloomShard, to show the cost of false sharing
+func BenchmarkShardLocks(b *testing.B) {
+	b.Run("padded", func(b *testing.B) {
+		shards := make([]bloomShard, runtime.GOMAXPROCS(0))
+		benchmarkLocks(b, func(i int) *sync.RWMutex { return &shards[i].mu })
+	})
+	b.Run("unpadded", func(b *testing.B) {
+		shards := make([]shardState, runtime.GOMAXPROCS(0))
+		benchmarkLocks(b, func(i int) *sync.RWMutex { return &shards[i].mu })
+	})
+}
+
+func benchmarkLocks(b *testing.B, lock func(i int) *sync.RWMutex) {
+	var next atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		mu := lock(int(next.Add(1)-1) % runtime.GOMAXPROCS(0))
+		for pb.Next() {
+			mu.Lock()
+			mu.Unlock()
+		}
+	})
+}
+
+func TestCalculateShardedParameters(t *testing.T) {
+	n, p := 100_000, 0.01
+	flat := CalculateOptimalParameters(n, p)
+
+	for _, shards := range []int{1, 16, 256} {
+		params := CalculateShardedParameters(n, p, shards)
+		if params.Shards != uint16(shards) || params.Capacity != uint64(n) {
+			t.Fatalf("Expected %d shards for %d items, got %+v", shards, n, params)
+		}
+		if params.Size < flat.Size {
+			t.Fatalf("Expected %d shards to need at least %d bits, got %d", shards, flat.Size, params.Size)
+		}
+
+		// a shard three standard deviations above the mean load stays close to p
+		mean := float64(n) / float64(shards)
+		load := mean + 3*math.Sqrt(mean)
+		rate := EstimateFalsePositiveRate(float64(params.Size/uint64(shards)), float64(params.NumHashFunctions), load)
+		if rate > p*1.05 {
+			t.Fatalf("Expected a heavily loaded shard of %d to stay near %f, got %f", shards, p, rate)
+		}
+	}
+}
+
+func TestShardedFalsePositiveRate(t *testing.T) {
+	n, p := 10_000, 0.01
+	params := CalculateShardedParameters(n, p, 64)
+	filter, err := NewSharded(params)
+	if err != nil {
+		t.Fatalf("Failed to create sharded filter: %v", err)
+	}
+	for _, item := range test.GenerateStringsOfLength(12, n) {
+		filter.Add(item)
+	}
+
+	testN := 50_000
+	falsePositives := 0
+	for _, item := range test.GenerateStringsOfLength(10, testN) {
+		if filter.Exists(item) {
+			falsePositives++
+		}
+	}
+
+	if rate := float64(falsePositives) / float64(testN); rate > 1.5*p {
+		t.Fatalf("False positive rate too high: %f", rate)
+	}
+}
+
+func TestShardedConcurrent(t *testing.T) {
+	filter := newShardedFilter(t, 10_000, 0.01, 16)
+	items := test.GenerateStringsOfLength(10, 4000)
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(items); i += 8 {
+				filter.Add(items[i])
+				filter.Exists(items[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, item := range items {
+		if !filter.Exists(item) {
+			t.Fatalf("Expected item %s to exist in the filter", item)
+		}
+	}
+	if added := filter.GetStatistics().AddedItems; added != uint64(len(items)) {
+		t.Fatalf("Expected %d added items, got %d", len(items), added)
+	}
+}
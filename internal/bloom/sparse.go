@@ -0,0 +1,191 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bloom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sync/atomic"
+)
+
+// A sparse payload lists the positions of the set bits instead of every bit,
+// which is far smaller for lightly filled filters. It is laid out as:
+//
+//	uvarint	number of set bits
+//	byte	Rice parameter k
+//	bits	for every set bit, the number of clear bits since the previous
+//			one Rice coded: gap >> k in unary (ones ended by a zero), then
+//			the k low bits of gap
+//
+// Bits are packed least significant first, the last byte is zero padded.
+
+// sparseEstimate Returns the estimated length in bytes of the sparse payload
+// of size bits of which setBits are set, and the Rice parameter to code it
+// with. Gaps between set bits are roughly geometric with mean size/setBits,
+// so a parameter of floor(log2(mean)) costs about k+2.5 bits per set bit.
+func sparseEstimate(size, setBits uint64) (uint64, uint8) {
+	if setBits == 0 {
+		return binary.MaxVarintLen64 + 1, 0
+	}
+
+	k := uint8(max(0, bits.Len64(size/setBits)-1))
+	return binary.MaxVarintLen64 + 1 + setBits*(uint64(k)+3)/8, k
+}
+
+// encodeSparse Rice codes the set bits of a store with parameter k. It gives
+// up and returns nil once the payload would exceed limit bytes, e.g. because
+// items were added while it was encoded.
+func encodeSparse(store *PackedBitStore, k uint8, limit int) []byte {
+	w := bitWriter{limit: limit - binary.MaxVarintLen64 - 1}
+	var count, next uint64
+	for i := range store.words {
+		word := atomic.LoadUint64(&store.words[i])
+		for ; word != 0; word &= word - 1 {
+			pos := uint64(i)*wordBits + uint64(bits.TrailingZeros64(word))
+			gap := pos - next
+			next = pos + 1
+			count++
+
+			for q := gap >> k; q > 0; q -= min(q, 32) {
+				w.write(1<<min(q, 32)-1, uint(min(q, 32)))
+			}
+			w.write(0, 1)
+			for shift := uint(0); shift < uint(k); shift += 32 {
+				n := min(uint(k)-shift, 32)
+				w.write(gap>>shift&(1<<n-1), n)
+			}
+		}
+		if w.full {
+			return nil
+		}
+	}
+	w.flush()
+	if w.full {
+		return nil
+	}
+
+	head := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+1+len(w.out)), count)
+	head = append(head, k)
+	return append(head, w.out...)
+}
+
+// decodeSparse Decodes a sparse payload of a filter of size bits into words.
+// The caller bounds size, it is allocated whatever the payload holds.
+func decodeSparse(data []byte, size uint64) ([]uint64, error) {
+	if size == 0 || size > maxFootprintBits {
+		return nil, fmt.Errorf("%w: invalid size %d", ErrInvalidFormat, size)
+	}
+	count, n := binary.Uvarint(data)
+	if n <= 0 || n >= len(data) || count > size {
+		return nil, fmt.Errorf("%w: bad sparse payload header", ErrInvalidFormat)
+	}
+	k := uint(data[n])
+	if k >= 64 {
+		return nil, fmt.Errorf("%w: bad Rice parameter %d", ErrInvalidFormat, k)
+	}
+
+	r := bitReader{data: data[n+1:]}
+	words := make([]uint64, (size+wordBits-1)/wordBits)
+	var next uint64
+	for range count {
+		var q uint64
+		for {
+			b, ok := r.read()
+			if !ok {
+				return nil, fmt.Errorf("%w: truncated sparse payload", ErrInvalidFormat)
+			}
+			if b == 0 {
+				break
+			}
+			if q++; q > size>>k {
+				return nil, fmt.Errorf("%w: set bit beyond the size", ErrInvalidFormat)
+			}
+		}
+
+		var low uint64
+		for j := range k {
+			b, ok := r.read()
+			if !ok {
+				return nil, fmt.Errorf("%w: truncated sparse payload", ErrInvalidFormat)
+			}
+			low |= b << j
+		}
+
+		gap := q<<k | low
+		if gap >= size-next {
+			return nil, fmt.Errorf("%w: set bit beyond the size", ErrInvalidFormat)
+		}
+		pos := next + gap
+		words[pos/wordBits] |= 1 << (pos % wordBits)
+		next = pos + 1
+	}
+
+	if (r.pos+7)/8 != uint64(len(r.data)) {
+		return nil, fmt.Errorf("%w: trailing bytes in sparse payload", ErrInvalidFormat)
+	}
+	return words, nil
+}
+
+// bitWriter packs bits least significant first, up to limit bytes
+type bitWriter struct {
+	out   []byte
+	acc   uint64
+	n     uint
+	limit int
+	full  bool
+}
+
+// write Appends the n ≤ 32 bits of v, which must not have higher bits set
+func (w *bitWriter) write(v uint64, n uint) {
+	w.acc |= v << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.emit()
+	}
+}
+
+// flush Appends the remaining bits, zero padded to a byte
+func (w *bitWriter) flush() {
+	if w.n > 0 {
+		w.emit()
+	}
+}
+
+func (w *bitWriter) emit() {
+	if len(w.out) >= w.limit {
+		w.full = true
+	} else {
+		w.out = append(w.out, byte(w.acc))
+	}
+	w.acc >>= 8
+	w.n = max(w.n, 8) - 8
+}
+
+// bitReader reads bits packed by a bitWriter
+type bitReader struct {
+	data []byte
+	pos  uint64
+}
+
+// read Returns the next bit, false once the data is exhausted
+func (r *bitReader) read() (uint64, bool) {
+	if r.pos >= uint64(len(r.data))*8 {
+		return 0, false
+	}
+	b := uint64(r.data[r.pos/8]>>(r.pos%8)) & 1
+	r.pos++
+	return b, true
+}
@@ -35,8 +35,102 @@ func CalculateOptimalParameters(n int, p float64) Parameters {
 	numHashFunc := CalculateNumHashFunctions(size, n)
 
 	return Parameters{
-		Size:              uint(size),
+		Size:              uint64(size),
 		FalsePositiveRate: p,
+		Capacity:          uint64(n),
 		NumHashFunctions:  uint8(numHashFunc),
+		HashAlgorithm:     HashMurmur3,
 	}
 }
+
+func EstimateFalsePositiveRate(size, k, n float64) float64 {
+	// Estimate the false positive rate of a bloom filter using the formula:
+	// p = pow(1 - exp(-k * n / size), k)
+	// Where,
+	// 		size : size of the bloom filter
+	// 		k : number of hash functions
+	// 		n : number of items added
+
+	if size == 0 {
+		return 1
+	}
+
+	return math.Pow(1-math.Exp(-k*n/size), k)
+}
+
+func EstimateCardinality(size, k, setBits float64) float64 {
+	// Estimate the number of distinct items in a bloom filter from its set
+	// bits using the Swamidass–Baldi formula:
+	// n = -(size / k) * ln(1 - setBits / size)
+	// Where,
+	// 		size : size of the bloom filter
+	// 		k : number of hash functions
+	// 		setBits : number of bits set
+
+	if size == 0 || k == 0 {
+		return 0
+	}
+
+	// a saturated filter would estimate infinity, count it as half a bit short
+	setBits = math.Min(setBits, size-0.5)
+
+	return -(size / k) * math.Log1p(-setBits/size)
+}
+
+func EstimateFalsePositiveRateFromBits(size, k, setBits float64) float64 {
+	// Estimate the current false positive rate of a bloom filter from its set
+	// bits, the chance that k random bits are all set:
+	// p = pow(setBits / size, k)
+	// Where,
+	// 		size : size of the bloom filter
+	// 		k : number of hash functions
+	// 		setBits : number of bits set
+
+	if size == 0 {
+		return 1
+	}
+
+	return math.Pow(setBits/size, k)
+}
+
+func CalculateShardedParameters(n int, p float64, shards int) Parameters {
+	// Items are spread over the shards by a hash, so the load of a shard is
+	// binomial with mean n/shards and standard deviation ~sqrt(n/shards).
+	// The false positive rate grows faster than linearly with the load, so
+	// shards sized for the mean would miss p on average. Each shard is sized
+	// for the mean plus three standard deviations instead:
+	// 		n_shard = n/shards + 3 * sqrt(n/shards)
+
+	if shards < 1 {
+		shards = 1
+	}
+
+	mean := float64(n) / float64(shards)
+	load := int(math.Ceil(mean + 3*math.Sqrt(mean)))
+	shard := CalculateOptimalParameters(load, p)
+
+	params := CalculateOptimalParameters(n, p)
+	params.Size = shard.Size * uint64(shards)
+	params.NumHashFunctions = shard.NumHashFunctions
+	params.Shards = uint16(shards)
+
+	return params
+}
+
+func deriveSeed(seed, i uint64) uint32 {
+	// Derive the seed of the i-th hash function from the master seed, so
+	// neighbouring indices get unrelated seeds.
+
+	return uint32(mix64(seed + (i+1)*0x9e3779b97f4a7c15))
+}
+
+func mix64(z uint64) uint64 {
+	// Scramble z with the splitmix64 finalizer, a bijection on uint64 whose
+	// output bits each depend on every input bit.
+
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+
+	return z
+}
@@ -0,0 +1,311 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+	"github.com/vinit-chauhan/go-bloomservice/internal/registry"
+)
+
+// Config holds the runtime configuration of the service, read from the
+// environment at startup.
+type Config struct {
+	// Port to serve the HTTP API on
+	Port int
+	// Expected number of elements in the filter
+	Capacity int
+	// Desired false positive probability
+	FalsePositiveRate float64
+	// Master seed of the filter's hash functions
+	Seed uint64
+	// Hash algorithm of the filter
+	HashAlgorithm bloom.HashAlgorithm
+	// Secret key mixed into every hash, nil for an unkeyed filter
+	SecretKey []byte
+	// Type of the filter
+	FilterType bloom.FilterType
+	// Width in bits of the counters of a counting filter
+	CounterWidth int
+	// Number of live generations of a rotating filter
+	Generations int
+	// Age after which a rotating filter starts a new generation
+	RotationInterval time.Duration
+	// Number of items after which a rotating filter starts a new generation
+	RotationItems uint64
+	// Number of independently locked shards of a sharded filter
+	Shards int
+	// Largest number of items accepted by a batch request
+	MaxBatchSize int
+	// Largest request body accepted in bytes
+	BodyLimit int
+	// Memory all filters together may hold in bytes, 0 for no limit
+	MemoryLimit uint64
+	// Memory a single imported filter may hold in bytes, at least 1
+	MaxImportSize uint64
+	// Header naming the tenant of a request when no API keys are configured
+	TenantHeader string
+	// Tenants besides the default one TenantHeader may name
+	Tenants []string
+	// Tenant of each API key, nil to take the tenant from TenantHeader
+	APIKeys map[string]string
+	// Number of filters each tenant may hold, 0 for no limit
+	TenantMaxFilters int
+	// Bits of memory the filters of each tenant may hold, 0 for no limit
+	TenantMaxBits uint64
+	// Item operations per second of each tenant, 0 for no limit
+	TenantOpsPerSecond float64
+	// Time after which an unused filter hibernates to disk, 0 to keep every
+	// filter in memory
+	HibernateAfter time.Duration
+	// Directory hibernated filters are written to, required with
+	// HibernateAfter
+	HibernateDir string
+}
+
+// Load Reads the configuration from the environment, falling back to the
+// defaults for unset variables
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	Config	: configuration of the service
+//	error	: error if a variable is set but malformed
+func Load() (Config, error) {
+	cfg := Config{
+		Port:              8080,
+		Capacity:          100_000,
+		FalsePositiveRate: 0.01,
+		Seed:              0,
+		HashAlgorithm:     bloom.HashMurmur3,
+		FilterType:        bloom.TypeStandard,
+		CounterWidth:      bloom.DefaultCounterWidth,
+		Generations:       bloom.DefaultGenerations,
+		Shards:            bloom.DefaultShards,
+		MaxBatchSize:      1000,
+		BodyLimit:         64 << 20,
+		MaxImportSize:     registry.DefaultMaxImport,
+		TenantHeader:      "X-Tenant",
+	}
+
+	var err error
+	if cfg.Port, err = intFromEnv("PORT", cfg.Port); err != nil {
+		return cfg, err
+	}
+	if cfg.Capacity, err = intFromEnv("CAPACITY", cfg.Capacity); err != nil {
+		return cfg, err
+	}
+	if cfg.FalsePositiveRate, err = floatFromEnv("FPP", cfg.FalsePositiveRate); err != nil {
+		return cfg, err
+	}
+	if cfg.Seed, err = uintFromEnv("BLOOM_SEED", cfg.Seed); err != nil {
+		return cfg, err
+	}
+	if cfg.HashAlgorithm, err = bloom.ParseHashAlgorithm(os.Getenv("BLOOM_HASH")); err != nil {
+		return cfg, err
+	}
+	if cfg.SecretKey, err = hexFromEnv("BLOOM_SECRET_KEY"); err != nil {
+		return cfg, err
+	}
+	if cfg.FilterType, err = bloom.ParseFilterType(os.Getenv("FILTER_TYPE")); err != nil {
+		return cfg, err
+	}
+	if cfg.CounterWidth, err = intFromEnv("COUNTER_WIDTH", cfg.CounterWidth); err != nil {
+		return cfg, err
+	}
+	if err = checkRange("COUNTER_WIDTH", cfg.CounterWidth, 1, math.MaxUint8); err != nil {
+		return cfg, err
+	}
+	if cfg.Generations, err = intFromEnv("GENERATIONS", cfg.Generations); err != nil {
+		return cfg, err
+	}
+	if err = checkRange("GENERATIONS", cfg.Generations, 1, math.MaxUint8); err != nil {
+		return cfg, err
+	}
+	if cfg.RotationInterval, err = durationFromEnv("ROTATION_INTERVAL", cfg.RotationInterval); err != nil {
+		return cfg, err
+	}
+	if cfg.RotationItems, err = uintFromEnv("ROTATION_ITEMS", cfg.RotationItems); err != nil {
+		return cfg, err
+	}
+	if cfg.Shards, err = intFromEnv("SHARDS", cfg.Shards); err != nil {
+		return cfg, err
+	}
+	if err = checkRange("SHARDS", cfg.Shards, 1, math.MaxUint16); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxBatchSize, err = intFromEnv("MAX_BATCH_SIZE", cfg.MaxBatchSize); err != nil {
+		return cfg, err
+	}
+	if err = checkRange("MAX_BATCH_SIZE", cfg.MaxBatchSize, 1, math.MaxInt); err != nil {
+		return cfg, err
+	}
+	if cfg.BodyLimit, err = intFromEnv("BODY_LIMIT", cfg.BodyLimit); err != nil {
+		return cfg, err
+	}
+	if cfg.MemoryLimit, err = uintFromEnv("MEMORY_LIMIT", cfg.MemoryLimit); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxImportSize, err = uintFromEnv("MAX_IMPORT_SIZE", cfg.MaxImportSize); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxImportSize == 0 {
+		return cfg, fmt.Errorf("invalid MAX_IMPORT_SIZE: must be at least 1")
+	}
+	if header := os.Getenv("TENANT_HEADER"); header != "" {
+		cfg.TenantHeader = header
+	}
+	cfg.Tenants = listFromEnv("TENANTS")
+	if cfg.APIKeys, err = keysFromEnv("API_KEYS"); err != nil {
+		return cfg, err
+	}
+	if cfg.TenantMaxFilters, err = intFromEnv("TENANT_MAX_FILTERS", cfg.TenantMaxFilters); err != nil {
+		return cfg, err
+	}
+	if cfg.TenantMaxBits, err = uintFromEnv("TENANT_MAX_BITS", cfg.TenantMaxBits); err != nil {
+		return cfg, err
+	}
+	if cfg.TenantOpsPerSecond, err = floatFromEnv("TENANT_OPS_PER_SECOND", cfg.TenantOpsPerSecond); err != nil {
+		return cfg, err
+	}
+	if cfg.HibernateAfter, err = durationFromEnv("HIBERNATE_AFTER", cfg.HibernateAfter); err != nil {
+		return cfg, err
+	}
+	if cfg.HibernateAfter > 0 && cfg.HibernateAfter < time.Second {
+		return cfg, fmt.Errorf("invalid HIBERNATE_AFTER %s: must be at least 1s", cfg.HibernateAfter)
+	}
+	// a temporary directory may be cleaned up under the snapshots, so it is
+	// not defaulted
+	cfg.HibernateDir = os.Getenv("HIBERNATE_DIR")
+	if cfg.HibernateAfter > 0 && cfg.HibernateDir == "" {
+		return cfg, fmt.Errorf("invalid HIBERNATE_DIR: must be set with HIBERNATE_AFTER")
+	}
+
+	return cfg, nil
+}
+
+// checkRange Checks that the value of key lies between lo and hi, e.g. so
+// it is not truncated to a narrower type
+func checkRange(key string, v, lo, hi int) error {
+	if v < lo || v > hi {
+		return fmt.Errorf("invalid %s %d: must be between %d and %d", key, v, lo, hi)
+	}
+	return nil
+}
+
+func intFromEnv(key string, def int) (int, error) {
+	raw, ok := os.LookupEnv(key)
+	if !ok || raw == "" {
+		return def, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return def, fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+func uintFromEnv(key string, def uint64) (uint64, error) {
+	raw, ok := os.LookupEnv(key)
+	if !ok || raw == "" {
+		return def, nil
+	}
+
+	v, err := strconv.ParseUint(raw, 0, 64)
+	if err != nil {
+		return def, fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+func floatFromEnv(key string, def float64) (float64, error) {
+	raw, ok := os.LookupEnv(key)
+	if !ok || raw == "" {
+		return def, nil
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return def, fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+func durationFromEnv(key string, def time.Duration) (time.Duration, error) {
+	raw, ok := os.LookupEnv(key)
+	if !ok || raw == "" {
+		return def, nil
+	}
+
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		return def, fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	return v, nil
+}
+
+func hexFromEnv(key string) ([]byte, error) {
+	raw, ok := os.LookupEnv(key)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	v, err := hex.DecodeString(raw)
+	if err != nil {
+		// do not echo the value, it is a secret
+		return nil, fmt.Errorf("invalid %s: must be hex encoded: %w", key, err)
+	}
+	return v, nil
+}
+
+// listFromEnv Reads a comma separated list, skipping empty entries
+func listFromEnv(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// keysFromEnv Reads a comma separated list of key=tenant pairs
+func keysFromEnv(key string) (map[string]string, error) {
+	raw, ok := os.LookupEnv(key)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	keys := make(map[string]string)
+	for i, pair := range strings.Split(raw, ",") {
+		apiKey, tenant, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || apiKey == "" || tenant == "" {
+			// do not echo the value, it holds secrets
+			return nil, fmt.Errorf("invalid %s: entry %d must be key=tenant", key, i)
+		}
+		keys[apiKey] = tenant
+	}
+	return keys, nil
+}
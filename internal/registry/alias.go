@@ -0,0 +1,131 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Alias is a second name of a filter of a tenant. Requests for the alias are
+// served by the filter it points at, so clients can be cut over to a rebuilt
+// filter by pointing the alias at it.
+type Alias struct {
+	// Name of the alias
+	Alias string `json:"alias"`
+	// Name of the filter the alias points at
+	Filter string `json:"filter"`
+}
+
+// SetAlias Points alias at a filter of the tenant, creating the alias or
+// moving it in one step. Requests resolving the alias afterwards are served
+// by the new filter, requests already holding the old one finish against it.
+// parameters:
+//
+//	alias	: name of the alias, letters, digits, '_', '.' and '-'
+//	filter	: name of the filter to point at, not another alias
+//
+// returns:
+//
+//	string	: filter the alias pointed at before, "" for a new alias
+//	error	: ErrInvalidName, ErrExists if a filter is named alias or
+//			  ErrNotFound if there is no such filter
+func (t *Tenant) SetAlias(alias, filter string) (string, error) {
+	if !validName.MatchString(alias) {
+		return "", fmt.Errorf("%w %q", ErrInvalidName, alias)
+	}
+
+	r := t.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := entryKey{tenant: t.id, name: alias}
+	if _, ok := r.entries[key]; ok {
+		return "", fmt.Errorf("%w: %s is a filter", ErrExists, alias)
+	}
+	if _, ok := r.entries[entryKey{tenant: t.id, name: filter}]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, filter)
+	}
+
+	previous := r.aliases[key]
+	r.aliases[key] = filter
+	return previous, nil
+}
+
+// Alias Returns the filter alias points at
+// parameters:
+//
+//	alias	: name of the alias
+//
+// returns:
+//
+//	string	: name of the filter
+//	error	: ErrAliasNotFound if there is no such alias
+func (t *Tenant) Alias(alias string) (string, error) {
+	r := t.registry
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filter, ok := r.aliases[entryKey{tenant: t.id, name: alias}]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrAliasNotFound, alias)
+	}
+	return filter, nil
+}
+
+// DeleteAlias Removes an alias of the tenant, the filter it points at is kept
+// parameters:
+//
+//	alias	: name of the alias
+//
+// returns:
+//
+//	error	: ErrAliasNotFound if there is no such alias
+func (t *Tenant) DeleteAlias(alias string) error {
+	r := t.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := entryKey{tenant: t.id, name: alias}
+	if _, ok := r.aliases[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrAliasNotFound, alias)
+	}
+	delete(r.aliases, key)
+	return nil
+}
+
+// Aliases Returns every alias of the tenant, ordered by name
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	[]Alias	: the aliases of the tenant
+func (t *Tenant) Aliases() []Alias {
+	r := t.registry
+	r.mu.RLock()
+	aliases := []Alias{}
+	for key, filter := range r.aliases {
+		if key.tenant == t.id {
+			aliases = append(aliases, Alias{Alias: key.name, Filter: filter})
+		}
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(aliases, func(a, b Alias) int { return strings.Compare(a.Alias, b.Alias) })
+	return aliases
+}
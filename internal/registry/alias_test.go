@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestAliases(t *testing.T) {
+	tenant := defaultTenant(t, New())
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+	old, _ := tenant.Create("users-2026-09", params)
+	rebuilt, _ := tenant.Create("users-2026-10", params)
+
+	if previous, err := tenant.SetAlias("users-current", "users-2026-09"); err != nil || previous != "" {
+		t.Fatalf("Failed to create alias: %q, %v", previous, err)
+	}
+	if entry, err := tenant.Get("users-current"); err != nil || entry != old {
+		t.Fatalf("Expected the alias to resolve to its filter, got %v", err)
+	}
+
+	// promote the rebuilt filter and retire the old one
+	if err := tenant.Delete("users-2026-09"); !errors.Is(err, ErrAliased) {
+		t.Fatalf("Expected ErrAliased deleting an aliased filter, got %v", err)
+	}
+	if previous, err := tenant.SetAlias("users-current", "users-2026-10"); err != nil || previous != "users-2026-09" {
+		t.Fatalf("Failed to swap alias: %q, %v", previous, err)
+	}
+	if entry, _ := tenant.Get("users-current"); entry != rebuilt {
+		t.Fatalf("Expected the alias to resolve to the rebuilt filter")
+	}
+	if err := tenant.Delete("users-2026-09"); err != nil {
+		t.Fatalf("Failed to delete the retired filter: %v", err)
+	}
+
+	steps := []struct {
+		Name     string
+		Alias    string
+		Filter   string
+		Expected error
+	}{
+		{"Invalid name", "a/b", "users-2026-10", ErrInvalidName},
+		{"Name of a filter", "users-2026-10", "users-2026-10", ErrExists},
+		{"Unknown filter", "orders", "missing", ErrNotFound},
+		{"Alias of an alias", "orders", "users-current", ErrNotFound},
+	}
+	for _, step := range steps {
+		if _, err := tenant.SetAlias(step.Alias, step.Filter); !errors.Is(err, step.Expected) {
+			t.Errorf("Step %q: expected %v, got %v", step.Name, step.Expected, err)
+		}
+	}
+	if _, err := tenant.Create("users-current", params); !errors.Is(err, ErrExists) {
+		t.Fatalf("Expected ErrExists creating a filter under an alias, got %v", err)
+	}
+
+	// aliases are namespaced per tenant
+	other, _ := tenant.registry.Tenant("team-a")
+	if _, err := other.Get("users-current"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected an alias of another tenant to be unknown, got %v", err)
+	}
+
+	if aliases := tenant.Aliases(); len(aliases) != 1 || aliases[0] != (Alias{Alias: "users-current", Filter: "users-2026-10"}) {
+		t.Fatalf("Expected one alias, got %v", aliases)
+	}
+	if err := tenant.DeleteAlias("users-current"); err != nil {
+		t.Fatalf("Failed to delete alias: %v", err)
+	}
+	if _, err := tenant.Alias("users-current"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("Expected ErrAliasNotFound after delete, got %v", err)
+	}
+	if err := tenant.Delete("users-2026-10"); err != nil {
+		t.Fatalf("Expected a filter without aliases to be deletable, got %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+// snapshotExt is the extension of the snapshot of a hibernated filter
+const snapshotExt = ".bloom"
+
+// snapshotDir is the directory the snapshots are kept in, inside the one
+// given to SetHibernation, so other files there are never touched
+const snapshotDir = "bloomservice-snapshots"
+
+// Info describes a filter. It is available without reloading a hibernated
+// filter, from the parameters and statistics it had when it hibernated.
+type Info struct {
+	// Parameters of the filter
+	Params bloom.Parameters
+	// Runtime statistics of the filter
+	Stats bloom.Statistics
+	// Memory the filter holds in bytes, 0 while it hibernates
+	SizeInBytes uint64
+	// Whether the filter hibernates on disk
+	Hibernated bool
+	// Time the filter was last used
+	LastAccess time.Time
+}
+
+// SetHibernation Sets the directory idle filters hibernate to, see
+// Hibernate. The snapshots are kept in its bloomservice-snapshots
+// subdirectory; those left there by a previous process are removed, so it
+// must not be shared between instances.
+// parameters:
+//
+//	dir	: directory to keep the snapshots in, "" to turn hibernation off
+//
+// returns:
+//
+//	error	: error if the directory cannot be created
+func (r *Registry) SetHibernation(dir string) error {
+	if dir != "" {
+		dir = filepath.Join(dir, snapshotDir)
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("registry: creating snapshot directory: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	r.snapshots = dir
+	r.mu.Unlock()
+	if dir == "" {
+		return nil
+	}
+
+	live := make(map[string]bool)
+	for _, entry := range r.list("") {
+		entry.mu.RLock()
+		live[entry.snapshot] = true
+		entry.mu.RUnlock()
+	}
+	// only the snapshots and the temporary files writeSnapshot creates
+	for _, pattern := range []string{"*" + snapshotExt, "*" + snapshotExt + ".*.tmp"} {
+		stale, _ := filepath.Glob(filepath.Join(dir, "*", pattern))
+		for _, path := range stale {
+			if !live[path] {
+				os.Remove(path)
+			}
+		}
+	}
+	return nil
+}
+
+// Hibernate Writes every filter not used since before to a snapshot file
+// and releases its memory, so memory is only spent on filters in use. The
+// next access reloads the filter transparently. Only filters that can be
+// serialized hibernate; others stay in memory and are reported with
+// ErrUnsupported the first time they are idle. Requests still holding a
+// filter are not waited for, so before must lie well in the past.
+// parameters:
+//
+//	before	: time filters must not have been used since, e.g. an hour ago
+//
+// returns:
+//
+//	int		: number of filters that hibernated
+//	error	: ErrSnapshot for filters that could not be written and
+//			  ErrUnsupported for filters that cannot be serialized, they
+//			  stay in memory
+func (r *Registry) Hibernate(before time.Time) (int, error) {
+	r.mu.RLock()
+	dir := r.snapshots
+	r.mu.RUnlock()
+	if dir == "" {
+		return 0, nil
+	}
+
+	hibernated := 0
+	var errs []error
+	for _, entry := range r.list("") {
+		ok, err := entry.hibernate(dir, before)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if ok {
+			hibernated++
+		}
+	}
+	return hibernated, errors.Join(errs...)
+}
+
+// Info Returns the description of the filter, without reloading it if it
+// hibernates
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	Info	: parameters, statistics, memory and hibernation state
+func (e *Entry) Info() Info {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	info := Info{LastAccess: time.Unix(0, e.accessed.Load())}
+	if e.filter == nil {
+		info.Params = e.params
+		info.Stats = e.stats
+		info.Hibernated = true
+		return info
+	}
+	info.Params = e.filter.GetParameters()
+	info.Stats = e.filter.GetStatistics()
+	info.SizeInBytes = e.filter.SizeInBytes()
+	return info
+}
+
+// Load Returns the current filter, reloading it from its snapshot if it
+// hibernated. A reloaded filter is booked against the memory limit and the
+// quota of its tenant again.
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	bloom.ProbabilisticFilter	: the current filter
+//	error						: ErrQuota or ErrMemoryLimit if the filter no
+//								  longer fits, ErrSnapshot if the snapshot
+//								  cannot be read
+func (e *Entry) Load() (bloom.ProbabilisticFilter, error) {
+	e.touch()
+	e.mu.RLock()
+	filter := e.filter
+	e.mu.RUnlock()
+	if filter != nil {
+		return filter, nil
+	}
+	return e.wake()
+}
+
+// touch Records an access to the filter
+func (e *Entry) touch() {
+	e.accessed.Store(time.Now().UnixNano())
+}
+
+// hibernate Writes the filter to a snapshot in dir and releases it if it was
+// not used since before
+func (e *Entry) hibernate(dir string, before time.Time) (bool, error) {
+	if e.accessed.Load() >= before.UnixNano() {
+		return false, nil
+	}
+
+	e.sleep.Lock()
+	defer e.sleep.Unlock()
+
+	// write the snapshot under the read lock, so requests and the memory
+	// accounting are not held up by the disk
+	e.mu.RLock()
+	live := e.filter
+	e.mu.RUnlock()
+	if e.deleted || live == nil {
+		return false, nil
+	}
+	writer, ok := live.(io.WriterTo)
+	if !ok {
+		kind := live.GetParameters().Type
+		if e.unsupported == kind {
+			return false, nil
+		}
+		e.unsupported = kind
+		return false, fmt.Errorf("%w: %s/%s is a %s filter, which cannot be serialized and stays in memory", ErrUnsupported, e.tenant.id, e.name, kind)
+	}
+	path := filepath.Join(dir, e.tenant.id, e.name+snapshotExt)
+	if err := writeSnapshot(path, writer); err != nil {
+		return false, fmt.Errorf("%w: hibernating %s/%s: %w", ErrSnapshot, e.tenant.id, e.name, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// a request that came in while the snapshot was written may have
+	// changed the filter, keep it in memory
+	if e.filter != live || e.accessed.Load() >= before.UnixNano() {
+		os.Remove(path)
+		return false, nil
+	}
+	e.params = e.filter.GetParameters()
+	e.stats = e.filter.GetStatistics()
+	e.filter = nil
+	e.snapshot = path
+	return true, nil
+}
+
+// wake Reloads a hibernated filter from its snapshot, checking the memory
+// limit and quota first
+func (e *Entry) wake() (bloom.ProbabilisticFilter, error) {
+	e.sleep.Lock()
+	defer e.sleep.Unlock()
+
+	e.mu.RLock()
+	filter, snapshot, footprint := e.filter, e.snapshot, e.footprint
+	e.mu.RUnlock()
+	if filter != nil {
+		// reloaded or replaced in the meantime
+		return filter, nil
+	}
+	if e.deleted {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, e.name)
+	}
+
+	r := e.tenant.registry
+	if err := r.check(e.tenant, footprint, e); err != nil {
+		return nil, err
+	}
+
+	// read the snapshot aside, the registry is not held up by the disk
+	filter, err := readSnapshot(snapshot, e.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reloading %s/%s: %w", ErrSnapshot, e.tenant.id, e.name, err)
+	}
+
+	// check again, other filters may have been created in the meantime
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.fits(e.tenant, footprint, e); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.filter != nil {
+		// replaced while the snapshot was read, which removed it
+		return e.filter, nil
+	}
+	e.filter = filter
+	e.snapshot = ""
+	os.Remove(snapshot)
+	return filter, nil
+}
+
+// discard Removes the snapshot of a deleted filter and keeps it from
+// hibernating again
+func (e *Entry) discard() {
+	e.sleep.Lock()
+	defer e.sleep.Unlock()
+
+	e.deleted = true
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.snapshot != "" {
+		os.Remove(e.snapshot)
+		e.snapshot = ""
+	}
+}
+
+// writeSnapshot Writes a filter to path, replacing the file only once the
+// filter is fully written
+func writeSnapshot(path string, writer io.WriterTo) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	buf := bufio.NewWriter(file)
+	if _, err := writer.WriteTo(buf); err != nil {
+		file.Close()
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// readSnapshot Reads the filter written to path by writeSnapshot
+func readSnapshot(path string, opts ...bloom.Option) (bloom.ProbabilisticFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return bloom.ReadExactly(bufio.NewReader(file), opts...)
+}
@@ -0,0 +1,182 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestHibernate(t *testing.T) {
+	dir := t.TempDir()
+	r := New(bloom.WithKey([]byte("secret")))
+	if err := r.SetHibernation(dir); err != nil {
+		t.Fatalf("Failed to set up hibernation: %v", err)
+	}
+	tenant := defaultTenant(t, r)
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+	users, _ := tenant.Create("users", params)
+	loadFilter(t, users).Add("apple")
+	counting := params
+	counting.Type = bloom.TypeCounting
+	if _, err := tenant.Create("counters", counting); err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+
+	if n, err := r.Hibernate(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("Expected filters in use to stay in memory, got %d, %v", n, err)
+	}
+	accessed := users.Info().LastAccess
+
+	// only filters that can be serialized hibernate, the others are reported
+	if n, err := r.Hibernate(time.Now()); !errors.Is(err, ErrUnsupported) || n != 1 {
+		t.Fatalf("Expected one filter to hibernate and ErrUnsupported, got %d, %v", n, err)
+	}
+	snapshot := filepath.Join(dir, snapshotDir, DefaultTenant, "users"+snapshotExt)
+	if _, err := os.Stat(snapshot); err != nil {
+		t.Fatalf("Expected a snapshot of the filter: %v", err)
+	}
+	info := users.Info()
+	if !info.Hibernated || info.SizeInBytes != 0 || info.Stats.AddedItems != 1 || !info.LastAccess.Equal(accessed) {
+		t.Fatalf("Expected a hibernated filter described from its snapshot, got %+v", info)
+	}
+	usage := r.Memory("")
+	if f := usage.Filters[1]; f.Name != "users" || !f.Hibernated || f.Booked != 0 {
+		t.Fatalf("Expected a hibernated filter to hold no memory, got %+v", f)
+	}
+
+	// the next access reloads it transparently
+	if !loadFilter(t, users).Exists("apple") {
+		t.Fatalf("Expected the reloaded filter to hold its items")
+	}
+	if _, err := os.Stat(snapshot); !os.IsNotExist(err) {
+		t.Fatalf("Expected the snapshot to be removed after reload, got %v", err)
+	}
+	if info := users.Info(); info.Hibernated || !info.LastAccess.After(accessed) {
+		t.Fatalf("Expected the reloaded filter to be in memory and accessed, got %+v", info)
+	}
+
+	// deleting a hibernated filter removes its snapshot
+	r.Hibernate(time.Now())
+	if err := tenant.Delete("users"); err != nil {
+		t.Fatalf("Failed to delete filter: %v", err)
+	}
+	if _, err := os.Stat(snapshot); !os.IsNotExist(err) {
+		t.Fatalf("Expected the snapshot to be removed on delete, got %v", err)
+	}
+	if _, err := users.Load(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound loading a deleted filter, got %v", err)
+	}
+}
+
+func TestHibernateFilterTypes(t *testing.T) {
+	for _, filterType := range bloom.FilterTypes {
+		t.Run(string(filterType), func(t *testing.T) {
+			r := New()
+			if err := r.SetHibernation(t.TempDir()); err != nil {
+				t.Fatalf("Failed to set up hibernation: %v", err)
+			}
+			params := bloom.CalculateOptimalParameters(1000, 0.01)
+			params.Type = filterType
+			params.RotationItems = 10_000
+			entry, err := defaultTenant(t, r).Create("users", params)
+			if err != nil {
+				t.Fatalf("Failed to create filter: %v", err)
+			}
+			loadFilter(t, entry).Add("apple")
+
+			n, err := r.Hibernate(time.Now())
+			if filterType == bloom.TypeStandard {
+				if err != nil || n != 1 || !entry.Info().Hibernated {
+					t.Fatalf("Expected the filter to hibernate, got %d, %v", n, err)
+				}
+			} else {
+				if !errors.Is(err, ErrUnsupported) || n != 0 || entry.Info().Hibernated {
+					t.Fatalf("Expected ErrUnsupported and the filter in memory, got %d, %v", n, err)
+				}
+				// reported once, not on every round
+				if n, err := r.Hibernate(time.Now()); err != nil || n != 0 {
+					t.Fatalf("Expected the filter to be reported once, got %d, %v", n, err)
+				}
+			}
+			if !loadFilter(t, entry).Exists("apple") {
+				t.Fatalf("Expected the filter to keep its items")
+			}
+		})
+	}
+}
+
+func TestHibernateMemoryLimit(t *testing.T) {
+	params := bloom.CalculateOptimalParameters(10_000, 0.01)
+	footprint, _ := bloom.Footprint(params)
+	r := New()
+	r.SetMemoryLimit(footprint)
+	r.SetHibernation(t.TempDir())
+	tenant := defaultTenant(t, r)
+
+	a, _ := tenant.Create("a", params)
+	if _, err := tenant.Create("b", params); !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("Expected ErrMemoryLimit next to a filter in memory, got %v", err)
+	}
+
+	// a hibernated filter frees its memory, and must fit again to reload
+	r.Hibernate(time.Now())
+	if _, err := tenant.Create("b", params); err != nil {
+		t.Fatalf("Expected a hibernated filter to free its memory, got %v", err)
+	}
+	if _, err := a.Load(); !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("Expected ErrMemoryLimit reloading next to a full registry, got %v", err)
+	}
+	if filter, ok := a.Filter(); ok || filter != nil {
+		t.Fatalf("Expected Filter to report the hibernated filter without reloading it")
+	}
+	if !a.Info().Hibernated {
+		t.Fatalf("Expected a refused reload to keep the filter hibernated")
+	}
+
+	// replacing a hibernated filter drops its snapshot
+	tenant.Delete("b")
+	if _, err := a.Recreate(params); err != nil {
+		t.Fatalf("Failed to recreate a hibernated filter: %v", err)
+	}
+	if info := a.Info(); info.Hibernated || info.Stats.AddedItems != 0 {
+		t.Fatalf("Expected the recreated filter in memory, got %+v", info)
+	}
+}
+
+func TestSetHibernationRemovesStaleSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	tenantDir := filepath.Join(dir, snapshotDir, DefaultTenant)
+	os.MkdirAll(tenantDir, 0o750)
+	stale := []string{
+		filepath.Join(tenantDir, "users"+snapshotExt),
+		filepath.Join(tenantDir, "users"+snapshotExt+".123.tmp"),
+	}
+	// files of other programs, in the directory or next to the snapshots
+	kept := []string{
+		filepath.Join(dir, "other.tmp"),
+		filepath.Join(dir, DefaultTenant+snapshotExt),
+		filepath.Join(tenantDir, "notes.tmp"),
+	}
+	for _, path := range append(slices.Clone(stale), kept...) {
+		os.WriteFile(path, []byte("stale"), 0o600)
+	}
+
+	if err := New().SetHibernation(dir); err != nil {
+		t.Fatalf("Failed to set up hibernation: %v", err)
+	}
+	for _, path := range stale {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("Expected the stale %s to be removed, got %v", filepath.Base(path), err)
+		}
+	}
+	for _, path := range kept {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("Expected %s to be kept, got %v", filepath.Base(path), err)
+		}
+	}
+}
@@ -0,0 +1,721 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+const (
+	// DefaultName is the name of the filter served by the unnamed routes
+	DefaultName = "default"
+	// DefaultTenant is the tenant of requests that do not name one
+	DefaultTenant = "default"
+	// DefaultMaxImport is the memory an imported filter may hold in bytes
+	// unless SetMaxImport changes it
+	DefaultMaxImport = 1 << 30
+)
+
+var (
+	// ErrNotFound is returned for a name no filter is registered under
+	ErrNotFound = errors.New("registry: filter not found")
+	// ErrExists is returned when creating a filter under a taken name
+	ErrExists = errors.New("registry: filter already exists")
+	// ErrInvalidName is returned for a name that cannot be used in a route
+	ErrInvalidName = errors.New("registry: invalid filter name")
+	// ErrInvalidTenant is returned for a tenant that cannot be used as a name
+	ErrInvalidTenant = errors.New("registry: invalid tenant")
+	// ErrDefault is returned when deleting the default filter of the default
+	// tenant
+	ErrDefault = errors.New("registry: the default filter cannot be deleted")
+	// ErrMemoryLimit is returned for a filter that does not fit into the
+	// memory left by the other filters
+	ErrMemoryLimit = errors.New("registry: memory limit exceeded")
+	// ErrTooLarge is returned for a filter larger than the memory limit or,
+	// for an import, the import maximum, which never fits
+	ErrTooLarge = errors.New("registry: filter is larger than the memory limit")
+	// ErrAliasNotFound is returned for a name no alias is registered under
+	ErrAliasNotFound = errors.New("registry: alias not found")
+	// ErrAliased is returned when deleting a filter an alias points at
+	ErrAliased = errors.New("registry: filter is the target of an alias")
+	// ErrSnapshot is returned when a filter cannot be written to or read
+	// back from its snapshot
+	ErrSnapshot = errors.New("registry: snapshot failed")
+	// ErrQuota is returned for a filter that exceeds the number of filters
+	// or bits a tenant may hold
+	ErrQuota = errors.New("registry: tenant quota exceeded")
+	// ErrUnsupported is returned for an operation the type of the filter
+	// does not support
+	ErrUnsupported = errors.New("registry: operation not supported by the filter type")
+)
+
+// validName matches names that are safe to use as a path segment
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Registry holds the named filters of a service instance, each owned by a
+// Tenant. It is safe for concurrent use.
+type Registry struct {
+	// options every filter is created with, e.g. WithKey
+	opts []bloom.Option
+
+	// mutex guarding the entries, aliases, tenants and limits
+	mu      sync.RWMutex
+	entries map[entryKey]*Entry
+	aliases map[entryKey]string
+	tenants map[string]*Tenant
+
+	// memory all filters together may hold in bytes, 0 for no limit
+	limit uint64
+
+	// memory an imported filter may hold in bytes, see SetMaxImport
+	maxImport uint64
+
+	// memory booked for filters being allocated outside the lock, see book
+	reserved uint64
+
+	// limits of every tenant
+	tenantLimits TenantLimits
+
+	// parameters of the default filter of a tenant, nil if it is not
+	// created on first use, see Tenant.Default
+	defaults *bloom.Parameters
+
+	// directory idle filters hibernate to, "" if hibernation is off
+	snapshots string
+}
+
+// entryKey identifies a filter or alias, names are unique per tenant
+type entryKey struct {
+	tenant, name string
+}
+
+// MemoryUsage is the memory held by the filters of a registry
+type MemoryUsage struct {
+	// Memory all filters together may hold in bytes, 0 for no limit
+	Limit uint64 `json:"limit_bytes"`
+	// Memory booked by all filters in bytes
+	Used uint64 `json:"used_bytes"`
+	// Per-filter memory of the tenant asked for, ordered by tenant and name
+	Filters []FilterMemory `json:"filters"`
+}
+
+// FilterMemory is the memory held by a single filter
+type FilterMemory struct {
+	// Tenant owning the filter
+	Tenant string `json:"tenant"`
+	// Name of the filter
+	Name string `json:"name"`
+	// Type of the filter
+	Type bloom.FilterType `json:"type"`
+	// Memory the filter holds in bytes
+	Bytes uint64 `json:"bytes"`
+	// Memory booked against the limit in bytes, more than Bytes for a
+	// filter that allocates as it goes, such as a rotating filter
+	Booked uint64 `json:"booked_bytes"`
+	// Whether the filter hibernates on disk, holding no memory
+	Hibernated bool `json:"hibernated"`
+}
+
+// Entry is a named filter of a Registry. The filter can be replaced while it
+// is in use, e.g. by Recreate or Import; callers get the current one with
+// Filter.
+type Entry struct {
+	// name the filter is registered under
+	name string
+	// time the filter was first created
+	created time.Time
+	// options the filter is created with, written under rebuild and sleep
+	// when its key is rotated, see Rekey
+	opts []bloom.Option
+	// tenant owning the filter, it is booked against its quota
+	tenant *Tenant
+
+	// mutex serializing the operations that build or merge filters with
+	// opts, so the bits of two keys are never mixed
+	rebuild sync.Mutex
+
+	// mutex serializing hibernation, reloads and deletion of the filter
+	sleep sync.Mutex
+	// whether the filter was deleted, guarded by sleep
+	deleted bool
+	// type the filter was last reported as unable to hibernate with,
+	// guarded by sleep, so an idle filter is reported once and not on every
+	// round of Hibernate
+	unsupported bloom.FilterType
+
+	// mutex guarding replacement of the filter
+	mu sync.RWMutex
+	// the filter, nil while it hibernates
+	filter bloom.ProbabilisticFilter
+	// footprint of the filter when it was created
+	footprint uint64
+	// path of the snapshot while the filter hibernates, "" otherwise
+	snapshot string
+	// parameters and statistics of the filter when it hibernated
+	params bloom.Parameters
+	stats  bloom.Statistics
+
+	// time of the last access in unix nanoseconds
+	accessed atomic.Int64
+}
+
+// New Creates an empty registry
+// parameters:
+//
+//	opts	: options every filter is created with, e.g. WithKey
+//
+// returns:
+//
+//	*Registry	: pointer to the Registry struct
+func New(opts ...bloom.Option) *Registry {
+	return &Registry{
+		opts:      opts,
+		entries:   make(map[entryKey]*Entry),
+		aliases:   make(map[entryKey]string),
+		tenants:   make(map[string]*Tenant),
+		maxImport: DefaultMaxImport,
+	}
+}
+
+// SetMemoryLimit Sets the memory all filters together may hold. Filters that
+// already exist are kept even if they exceed it; new ones are refused until
+// enough memory is freed.
+// parameters:
+//
+//	limit	: memory limit in bytes, 0 for no limit
+//
+// returns:
+//
+//	none
+func (r *Registry) SetMemoryLimit(limit uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.limit = limit
+}
+
+// SetMaxImport Sets the memory a single imported filter may hold. It bounds
+// what an upload decodes to even without a memory limit, as a sparse upload
+// of a few bytes can declare a filter of any size; the memory limit lowers
+// it further where it is smaller.
+// parameters:
+//
+//	bytes	: memory an imported filter may hold in bytes, at least 1
+//
+// returns:
+//
+//	none
+func (r *Registry) SetMaxImport(bytes uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.maxImport = max(bytes, 1)
+}
+
+// Memory Returns the memory held by the filters of a tenant, and the memory
+// booked by every filter against the limit
+// parameters:
+//
+//	tenant	: tenant to list the filters of, "" for every tenant
+//
+// returns:
+//
+//	MemoryUsage	: per-filter and total memory
+func (r *Registry) Memory(tenant string) MemoryUsage {
+	r.mu.RLock()
+	limit := r.limit
+	r.mu.RUnlock()
+
+	usage := MemoryUsage{Limit: limit, Filters: []FilterMemory{}}
+	for _, entry := range r.list("") {
+		info := entry.Info()
+		booked := entry.booked()
+		usage.Used += booked
+		if tenant != "" && entry.tenant.id != tenant {
+			continue
+		}
+		usage.Filters = append(usage.Filters, FilterMemory{
+			Tenant:     entry.tenant.id,
+			Name:       entry.name,
+			Type:       info.Params.Type,
+			Bytes:      info.SizeInBytes,
+			Booked:     booked,
+			Hibernated: info.Hibernated,
+		})
+	}
+	return usage
+}
+
+// list Returns the filters of a tenant, or of every tenant for "", ordered
+// by tenant and name
+func (r *Registry) list(tenant string) []*Entry {
+	r.mu.RLock()
+	entries := make([]*Entry, 0, len(r.entries))
+	for key, entry := range r.entries {
+		if tenant == "" || key.tenant == tenant {
+			entries = append(entries, entry)
+		}
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(entries, func(a, b *Entry) int {
+		return cmp.Or(strings.Compare(a.tenant.id, b.tenant.id), strings.Compare(a.name, b.name))
+	})
+	return entries
+}
+
+// check Checks that a filter of footprint bytes fits in place of skip, see
+// fits
+func (r *Registry) check(t *Tenant, footprint uint64, skip *Entry) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.fits(t, footprint, skip)
+}
+
+// fits Checks that a filter of footprint bytes owned by t fits into the
+// memory limit and the quota of t next to every filter but skip, the filter
+// it replaces. The caller holds the lock.
+func (r *Registry) fits(t *Tenant, footprint uint64, skip *Entry) error {
+	if r.limit > 0 && footprint > r.limit {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrTooLarge, footprint, r.limit)
+	}
+
+	used, owned := r.reserved, t.reserved
+	filters := 1 + t.creating
+	for _, entry := range r.entries {
+		if entry == skip {
+			continue
+		}
+		booked := entry.booked()
+		used += booked
+		if entry.tenant == t {
+			owned += booked
+			filters++
+		}
+	}
+
+	limits := r.tenantLimits
+	if limits.MaxFilters > 0 && filters > limits.MaxFilters {
+		return fmt.Errorf("%w: tenant %s may hold %d filters", ErrQuota, t.id, limits.MaxFilters)
+	}
+	if limits.MaxBits > 0 && (owned+footprint)*8 > limits.MaxBits {
+		return fmt.Errorf("%w: tenant %s may hold %d bits, %d in use", ErrQuota, t.id, limits.MaxBits, owned*8)
+	}
+	if r.limit > 0 && used+footprint > r.limit {
+		return fmt.Errorf("%w: %d bytes requested, %d of %d in use", ErrMemoryLimit, footprint, used, r.limit)
+	}
+	return nil
+}
+
+// importLimit Returns the memory an imported filter may hold, the smaller of
+// the import maximum and the memory limit
+func (r *Registry) importLimit() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.limit > 0 {
+		return min(r.maxImport, r.limit)
+	}
+	return r.maxImport
+}
+
+// book Books the memory of a filter of footprint bytes owned by t, and the
+// filter itself unless it replaces skip, so it can be allocated without the
+// lock. The caller holds the lock, has checked that the filter fits and
+// hands the booking back with release once the filter is registered or
+// failed to allocate.
+func (r *Registry) book(t *Tenant, footprint uint64, skip *Entry) {
+	r.reserved += footprint
+	t.reserved += footprint
+	if skip == nil {
+		t.creating++
+	}
+}
+
+// release Hands back a booking made by book, the caller holds the lock
+func (r *Registry) release(t *Tenant, footprint uint64, skip *Entry) {
+	r.reserved -= footprint
+	t.reserved -= footprint
+	if skip == nil {
+		t.creating--
+	}
+}
+
+// Name Returns the name the filter is registered under
+func (e *Entry) Name() string {
+	return e.name
+}
+
+// Tenant Returns the tenant owning the filter
+func (e *Entry) Tenant() *Tenant {
+	return e.tenant
+}
+
+// Created Returns the time the filter was first created
+func (e *Entry) Created() time.Time {
+	return e.created
+}
+
+// Filter Returns the current filter if it is in memory, safe to call while
+// it is replaced. A hibernated filter is not reloaded, callers that need it
+// call Load.
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	bloom.ProbabilisticFilter	: the current filter, nil if it hibernates
+//	bool						: whether the filter is in memory
+func (e *Entry) Filter() (bloom.ProbabilisticFilter, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.filter == nil {
+		return nil, false
+	}
+	e.touch()
+	return e.filter, true
+}
+
+// Recreate Replaces the filter with a new, empty filter created from params.
+// The new filter takes the place of the old one in the memory limit.
+// parameters:
+//
+//	params	: parameters of the new filter
+//
+// returns:
+//
+//	bloom.ProbabilisticFilter	: the new filter
+//	error						: ErrQuota, ErrTooLarge, ErrMemoryLimit or an
+//								  error if the parameters are invalid
+func (e *Entry) Recreate(params bloom.Parameters) (bloom.ProbabilisticFilter, error) {
+	footprint, err := bloom.Footprint(params)
+	if err != nil {
+		return nil, err
+	}
+
+	e.rebuild.Lock()
+	defer e.rebuild.Unlock()
+
+	r := e.tenant.registry
+	r.mu.Lock()
+	if err := r.fits(e.tenant, footprint, e); err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	r.book(e.tenant, footprint, e)
+	r.mu.Unlock()
+
+	// allocate without the lock, the registry is not held up by a large
+	// filter
+	filter, err := e.newFilter(params)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.release(e.tenant, footprint, e)
+	if err != nil {
+		return nil, err
+	}
+	e.replace(filter, footprint)
+	return filter, nil
+}
+
+// newFilter Creates a filter of the entry from params. A scalable filter
+// checks every layer it adds against the memory limit and the quota of the
+// tenant, see allowGrowth.
+func (e *Entry) newFilter(params bloom.Parameters) (bloom.ProbabilisticFilter, error) {
+	return bloom.NewFilter(params, append(slices.Clip(e.opts), bloom.WithGrowth(e.allowGrowth))...)
+}
+
+// allowGrowth Checks that the filter may grow by bytes next to the other
+// filters, see bloom.WithGrowth. The filter exists already, so growing past
+// the limit is ErrMemoryLimit even where it alone would exceed it.
+func (e *Entry) allowGrowth(bytes uint64) error {
+	err := e.tenant.registry.check(e.tenant, e.booked()+bytes, e)
+	if errors.Is(err, ErrTooLarge) {
+		return fmt.Errorf("%w: growing %s by %d bytes: %w", ErrMemoryLimit, e.name, bytes, err)
+	}
+	return err
+}
+
+// Import Replaces the filter with a serialized filter read from r. The
+// filter is read and validated aside and only swapped in once complete, so
+// an invalid upload, or one that does not fit into the memory limit, the
+// import maximum or the quota of the tenant, leaves the live filter untouched.
+// parameters:
+//
+//	r	: reader holding exactly one serialized filter
+//
+// returns:
+//
+//	bloom.ProbabilisticFilter	: the new filter
+//	error						: bloom.ErrInvalidFormat or bloom.ErrKeyMismatch if
+//								  the filter cannot be read, ErrQuota,
+//								  ErrTooLarge or ErrMemoryLimit if it does not
+//								  fit
+func (e *Entry) Import(r io.Reader) (bloom.ProbabilisticFilter, error) {
+	e.rebuild.Lock()
+	defer e.rebuild.Unlock()
+
+	// refuse a filter that does not fit before decoding it, a sparse upload
+	// decodes to far more memory than it takes
+	br := bufio.NewReader(r)
+	params, err := bloom.PeekParameters(br)
+	if err != nil {
+		return nil, err
+	}
+	footprint, err := bloom.Footprint(params)
+	if err != nil {
+		return nil, err
+	}
+	reg := e.tenant.registry
+	maxImport := reg.importLimit()
+	if footprint > maxImport {
+		return nil, fmt.Errorf("%w: importing %d bytes, imports may hold %d", ErrTooLarge, footprint, maxImport)
+	}
+	if err := reg.check(e.tenant, footprint, e); err != nil {
+		return nil, err
+	}
+
+	// the decoder is held to the same bound, whatever the header claims
+	opts := append(slices.Clip(e.opts), bloom.WithMaxSize(min(maxImport, math.MaxUint64/8)*8))
+	filter, err := bloom.ReadExactly(br, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// check again, other filters may have been created in the meantime
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if err := reg.fits(e.tenant, footprint, e); err != nil {
+		return nil, err
+	}
+	e.replace(filter, footprint)
+	return filter, nil
+}
+
+// Merge Merges serialized bits into the filter, see bloom.Merge
+// parameters:
+//
+//	params		: parameters of the filter the bits were taken from
+//	data		: packed bits as returned by PackedBitStore.Bytes
+//	intersect	: intersect with the bits instead of taking the union
+//
+// returns:
+//
+//	bloom.ProbabilisticFilter	: the merged filter
+//	error						: bloom.ErrIncompatible if the bits cannot be merged
+func (e *Entry) Merge(params bloom.Parameters, data []byte, intersect bool) (bloom.ProbabilisticFilter, error) {
+	e.rebuild.Lock()
+	defer e.rebuild.Unlock()
+
+	filter, err := e.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer e.mu.RUnlock()
+
+	return filter, bloom.Merge(filter, params, data, intersect, e.opts...)
+}
+
+// MergeFrom Merges a serialized filter read from r into the filter. Its
+// header is checked against the filter before anything is decoded, then the
+// filter is read aside; it must have been written with the same secret key.
+// parameters:
+//
+//	r			: reader holding exactly one serialized filter
+//	intersect	: intersect with the filter instead of taking the union
+//
+// returns:
+//
+//	bloom.ProbabilisticFilter	: the merged filter
+//	error						: bloom.ErrInvalidFormat or bloom.ErrIncompatible
+//								  if the filter cannot be merged
+func (e *Entry) MergeFrom(r io.Reader, intersect bool) (bloom.ProbabilisticFilter, error) {
+	e.rebuild.Lock()
+	defer e.rebuild.Unlock()
+
+	current, err := e.Load()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	if err := bloom.CheckMergeable(current, br); err != nil {
+		return nil, err
+	}
+	opts := append(slices.Clip(e.opts), bloom.WithMaxSize(current.GetParameters().Size))
+	other, err := bloom.ReadExactly(br, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := e.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer e.mu.RUnlock()
+
+	live, ok := filter.(*bloom.BloomFilter)
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot merge into a %s filter", bloom.ErrIncompatible, filter.GetParameters().Type)
+	}
+	if intersect {
+		return live, live.Intersect(other)
+	}
+	return live, live.Union(other)
+}
+
+// Rekey Rotates the secret key of the filter and rebuilds its bits from
+// source, see bloom.BloomFilter.Rekey. Snapshots, imports and merges of the
+// filter use the new key from then on. Items added while the bits are
+// rebuilt are carried over, items added before Rekey was called must be
+// replayed by source. The rebuilt bits are booked against the memory limit
+// and the quota of the tenant until they replace the old ones.
+// parameters:
+//
+//	key		: new secret key
+//	source	: source replaying every item of the filter
+//
+// returns:
+//
+//	error	: ErrUnsupported for a filter that is not a standard one, ErrQuota
+//			  or ErrMemoryLimit if the rebuilt bits do not fit,
+//			  bloom.ErrEmptyKey or the error of source
+func (e *Entry) Rekey(key []byte, source bloom.ReplaySource) error {
+	e.rebuild.Lock()
+	defer e.rebuild.Unlock()
+
+	// the filter must not hibernate with bits of the old key and be
+	// reloaded with the new one
+	filter, err := e.hold()
+	if err != nil {
+		return err
+	}
+	defer e.sleep.Unlock()
+
+	live, ok := filter.(*bloom.BloomFilter)
+	if !ok {
+		return fmt.Errorf("%w: cannot rekey a %s filter", ErrUnsupported, filter.GetParameters().Type)
+	}
+
+	r := e.tenant.registry
+	bits := live.SizeInBytes()
+	r.mu.Lock()
+	if err := r.fits(e.tenant, e.booked()+bits, e); err != nil {
+		r.mu.Unlock()
+		if errors.Is(err, ErrTooLarge) {
+			return fmt.Errorf("%w: rebuilding %s takes %d more bytes: %w", ErrMemoryLimit, e.name, bits, err)
+		}
+		return err
+	}
+	r.book(e.tenant, bits, e)
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.release(e.tenant, bits, e)
+		r.mu.Unlock()
+	}()
+
+	if err := live.Rekey(key, source); err != nil {
+		return err
+	}
+	e.opts = append(slices.Clip(e.opts), bloom.WithKey(key))
+	return nil
+}
+
+// hold Returns the current filter with sleep held, so it is neither
+// hibernated nor deleted until the caller releases it. A hibernated filter
+// is reloaded first, see Load.
+func (e *Entry) hold() (bloom.ProbabilisticFilter, error) {
+	for {
+		if _, err := e.Load(); err != nil {
+			return nil, err
+		}
+		e.sleep.Lock()
+		if e.deleted {
+			e.sleep.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, e.name)
+		}
+		e.mu.RLock()
+		filter := e.filter
+		e.mu.RUnlock()
+		if filter != nil {
+			return filter, nil
+		}
+		// hibernated again in between
+		e.sleep.Unlock()
+	}
+}
+
+// acquire Returns the current filter with the read lock held, so it is
+// neither replaced nor hibernated until the caller releases it. A hibernated
+// filter is reloaded first, see Load.
+func (e *Entry) acquire() (bloom.ProbabilisticFilter, error) {
+	for {
+		if _, err := e.Load(); err != nil {
+			return nil, err
+		}
+		e.mu.RLock()
+		if e.filter != nil {
+			return e.filter, nil
+		}
+		// hibernated again in between
+		e.mu.RUnlock()
+	}
+}
+
+// replace Swaps in a new filter of the given footprint, discarding the
+// snapshot if the filter hibernated
+func (e *Entry) replace(filter bloom.ProbabilisticFilter, footprint uint64) {
+	e.touch()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.snapshot != "" {
+		os.Remove(e.snapshot)
+		e.snapshot = ""
+	}
+	e.filter = filter
+	e.footprint = footprint
+}
+
+// booked Returns the memory booked for the filter: its footprint, or what it
+// holds if it grew past it, and nothing while it hibernates
+func (e *Entry) booked() uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.filter == nil {
+		return 0
+	}
+	return max(e.footprint, e.filter.SizeInBytes())
+}
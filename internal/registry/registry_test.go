@@ -0,0 +1,368 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func defaultTenant(t *testing.T, r *Registry) *Tenant {
+	t.Helper()
+	tenant, err := r.Tenant(DefaultTenant)
+	if err != nil {
+		t.Fatalf("Failed to get the default tenant: %v", err)
+	}
+	return tenant
+}
+
+// loadFilter Returns the filter of an entry, reloading it if it hibernates
+func loadFilter(t *testing.T, e *Entry) bloom.ProbabilisticFilter {
+	t.Helper()
+	filter, err := e.Load()
+	if err != nil {
+		t.Fatalf("Failed to load filter %s: %v", e.Name(), err)
+	}
+	return filter
+}
+
+func TestRegistry(t *testing.T) {
+	d := defaultTenant(t, New())
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+
+	if _, err := d.Create(DefaultName, params); err != nil {
+		t.Fatalf("Failed to create the default filter: %v", err)
+	}
+	team, err := d.Create("team-a", params)
+	if err != nil {
+		t.Fatalf("Failed to create a named filter: %v", err)
+	}
+	if _, err := d.Create("team-a", params); !errors.Is(err, ErrExists) {
+		t.Fatalf("Expected ErrExists for a taken name, got %v", err)
+	}
+	for _, name := range []string{"", "a/b", "-leading", "with space"} {
+		if _, err := d.Create(name, params); !errors.Is(err, ErrInvalidName) {
+			t.Fatalf("Expected ErrInvalidName for %q, got %v", name, err)
+		}
+	}
+
+	// filters are independent
+	loadFilter(t, team).Add("apple")
+	def, err := d.Get(DefaultName)
+	if err != nil {
+		t.Fatalf("Failed to get the default filter: %v", err)
+	}
+	if loadFilter(t, def).Exists("apple") {
+		t.Fatalf("Expected an item of one filter to be absent from another")
+	}
+
+	if names := d.List(); len(names) != 2 || names[0].Name() != DefaultName || names[1].Name() != "team-a" {
+		t.Fatalf("Expected the filters ordered by name, got %v", names)
+	}
+
+	if err := d.Delete(DefaultName); !errors.Is(err, ErrDefault) {
+		t.Fatalf("Expected ErrDefault deleting the default filter, got %v", err)
+	}
+	if err := d.Delete("team-a"); err != nil {
+		t.Fatalf("Failed to delete a filter: %v", err)
+	}
+	if _, err := d.Get("team-a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := d.Delete("team-a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestEntryReplace(t *testing.T) {
+	d := defaultTenant(t, New(bloom.WithKey([]byte("secret"))))
+	entry, err := d.Create("users", bloom.CalculateOptimalParameters(1000, 0.01))
+	if err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+	loadFilter(t, entry).Add("apple")
+
+	data, err := loadFilter(t, entry).(*bloom.BloomFilter).MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal filter: %v", err)
+	}
+
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+	params.Type = bloom.TypeCounting
+	if _, err := entry.Recreate(params); err != nil {
+		t.Fatalf("Failed to recreate filter: %v", err)
+	}
+	if got := loadFilter(t, entry).GetParameters().Type; got != bloom.TypeCounting {
+		t.Fatalf("Expected a counting filter after recreate, got %s", got)
+	}
+	if loadFilter(t, entry).Exists("apple") {
+		t.Fatalf("Expected the recreated filter to be empty")
+	}
+
+	// the entry keeps its key, so the export reads back
+	if _, err := entry.Import(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Failed to import filter: %v", err)
+	}
+	if !loadFilter(t, entry).Exists("apple") {
+		t.Fatalf("Expected the imported filter to hold its items")
+	}
+
+	unkeyed, _ := defaultTenant(t, New()).Create("users", bloom.CalculateOptimalParameters(1000, 0.01))
+	if _, err := unkeyed.Import(bytes.NewReader(data)); !errors.Is(err, bloom.ErrKeyMismatch) {
+		t.Fatalf("Expected ErrKeyMismatch importing into an unkeyed registry, got %v", err)
+	}
+}
+
+func TestMergeFromIncompatible(t *testing.T) {
+	d := defaultTenant(t, New())
+	entry, err := d.Create("users", bloom.CalculateOptimalParameters(1000, 0.01))
+	if err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+
+	// a filter of another size is refused from its 80 byte header alone
+	data, _ := bloom.New(bloom.CalculateOptimalParameters(1_000_000, 0.01)).MarshalBinary()
+	if _, err := entry.MergeFrom(bytes.NewReader(data[:80]), false); !errors.Is(err, bloom.ErrIncompatible) {
+		t.Fatalf("Expected ErrIncompatible merging a filter of another size, got %v", err)
+	}
+
+	data, _ = bloom.New(bloom.CalculateOptimalParameters(1000, 0.01)).MarshalBinary()
+	if _, err := entry.MergeFrom(bytes.NewReader(data), false); err != nil {
+		t.Fatalf("Failed to merge a compatible filter: %v", err)
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	params := bloom.CalculateOptimalParameters(10_000, 0.01)
+	footprint, err := bloom.Footprint(params)
+	if err != nil {
+		t.Fatalf("Failed to compute footprint: %v", err)
+	}
+
+	r := New()
+	r.SetMemoryLimit(2*footprint + footprint/2)
+	d := defaultTenant(t, r)
+	if _, err := d.Create("a", params); err != nil {
+		t.Fatalf("Failed to create a filter within the limit: %v", err)
+	}
+	b, err := d.Create("b", params)
+	if err != nil {
+		t.Fatalf("Failed to create a filter within the limit: %v", err)
+	}
+	if _, err := d.Create("c", params); !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("Expected ErrMemoryLimit past the limit, got %v", err)
+	}
+	if _, err := d.Create("huge", bloom.CalculateOptimalParameters(1e10, 1e-9)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge for a filter larger than the limit, got %v", err)
+	}
+
+	// a replaced filter frees its memory for the new one
+	if _, err := b.Recreate(params); err != nil {
+		t.Fatalf("Failed to recreate a filter in place: %v", err)
+	}
+	rotating := params
+	rotating.Type = bloom.TypeRotating
+	rotating.RotationItems = 100
+	if _, err := b.Recreate(rotating); !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("Expected ErrMemoryLimit for every generation of a rotating filter, got %v", err)
+	}
+
+	usage := r.Memory("")
+	if usage.Limit != 2*footprint+footprint/2 || usage.Used != 2*footprint || len(usage.Filters) != 2 {
+		t.Fatalf("Expected two filters of %d bytes, got %+v", footprint, usage)
+	}
+	if f := usage.Filters[0]; f.Name != "a" || f.Bytes != footprint || f.Booked != footprint {
+		t.Fatalf("Expected filter a to hold %d bytes, got %+v", footprint, f)
+	}
+
+	if err := d.Delete("b"); err != nil {
+		t.Fatalf("Failed to delete filter: %v", err)
+	}
+	if _, err := d.Create("c", params); err != nil {
+		t.Fatalf("Expected a deleted filter to free its memory, got %v", err)
+	}
+}
+
+func TestCreateConcurrentMemoryLimit(t *testing.T) {
+	params := bloom.CalculateOptimalParameters(100_000, 0.01)
+	footprint, _ := bloom.Footprint(params)
+	r := New()
+	r.SetMemoryLimit(3 * footprint)
+	d := defaultTenant(t, r)
+
+	// filters are allocated outside the lock, the booking keeps concurrent
+	// creates within the limit
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := d.Create("f"+strconv.Itoa(i), params); err == nil {
+				created.Add(1)
+			} else if !errors.Is(err, ErrMemoryLimit) {
+				t.Errorf("Expected ErrMemoryLimit, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := created.Load(); got != 3 {
+		t.Fatalf("Expected 3 filters to fit, got %d", got)
+	}
+	if usage := r.Memory(""); usage.Used != 3*footprint || r.reserved != 0 {
+		t.Fatalf("Expected 3 filters booked and nothing reserved, got %+v and %d reserved", usage, r.reserved)
+	}
+}
+
+func TestScalableMemoryLimit(t *testing.T) {
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+	params.Type = bloom.TypeScalable
+	footprint, _ := bloom.Footprint(params)
+	r := New()
+	r.SetMemoryLimit(4 * footprint)
+	entry, err := defaultTenant(t, r).Create("growing", params)
+	if err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+
+	// layers double, so the second one fits and the third does not
+	filter := loadFilter(t, entry).(bloom.Inserter)
+	var refused error
+	for i := 0; i < 10_000 && refused == nil; i++ {
+		refused = filter.Insert(strconv.Itoa(i))
+	}
+	if !errors.Is(refused, ErrMemoryLimit) {
+		t.Fatalf("Expected ErrMemoryLimit growing past the limit, got %v", refused)
+	}
+	if usage := r.Memory(""); usage.Used > usage.Limit {
+		t.Fatalf("Expected the filter to stay within the limit, got %+v", usage)
+	}
+}
+
+func TestImportMemoryLimit(t *testing.T) {
+	small := bloom.CalculateOptimalParameters(1000, 0.01)
+	footprint, _ := bloom.Footprint(small)
+	r := New()
+	r.SetMemoryLimit(footprint)
+	entry, err := defaultTenant(t, r).Create("users", small)
+	if err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+
+	live := loadFilter(t, entry)
+
+	// an almost empty filter is tiny on the wire, but not in memory
+	large := bloom.New(bloom.CalculateOptimalParameters(1_000_000, 0.01))
+	large.Add("apple")
+	data, _ := large.MarshalBinary()
+	if _, err := entry.Import(bytes.NewReader(data)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge importing a filter larger than the limit, got %v", err)
+	}
+	if loadFilter(t, entry) != live {
+		t.Fatalf("Expected a refused import to keep the live filter")
+	}
+}
+
+func TestImportMaxSize(t *testing.T) {
+	r := New()
+	entry, err := defaultTenant(t, r).Create("users", bloom.CalculateOptimalParameters(1000, 0.01))
+	if err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+
+	// a tiny sparse upload whose header declares a huge filter is refused
+	// without a memory limit, before anything is decoded
+	data, _ := bloom.New(bloom.CalculateOptimalParameters(1000, 0.01)).MarshalBinary()
+	binary.LittleEndian.PutUint64(data[8:], 1<<40)
+	if _, err := entry.Import(bytes.NewReader(data)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge importing a %d byte upload declaring 2^40 bits, got %v", len(data), err)
+	}
+
+	large := bloom.New(bloom.CalculateOptimalParameters(1_000_000, 0.01))
+	data, _ = large.MarshalBinary()
+	r.SetMaxImport(large.SizeInBytes() / 2)
+	if _, err := entry.Import(bytes.NewReader(data)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge importing past the import maximum, got %v", err)
+	}
+	r.SetMaxImport(DefaultMaxImport)
+	if _, err := entry.Import(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Failed to import within the import maximum: %v", err)
+	}
+}
+
+func TestEntryRekey(t *testing.T) {
+	r := New(bloom.WithKey([]byte("old")))
+	if err := r.SetHibernation(t.TempDir()); err != nil {
+		t.Fatalf("Failed to set up hibernation: %v", err)
+	}
+	tenant := defaultTenant(t, r)
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+	users, _ := tenant.Create("users", params)
+	loadFilter(t, users).Add("apple")
+
+	source := func(add func(item string)) error {
+		add("apple")
+		return nil
+	}
+	if err := users.Rekey([]byte("new"), source); err != nil {
+		t.Fatalf("Failed to rekey: %v", err)
+	}
+
+	// the snapshot is written and read back with the new key
+	if n, err := r.Hibernate(time.Now()); err != nil || n != 1 {
+		t.Fatalf("Expected the rekeyed filter to hibernate, got %d, %v", n, err)
+	}
+	if !loadFilter(t, users).Exists("apple") {
+		t.Fatalf("Expected the reloaded filter to hold its items")
+	}
+
+	// exports of the filter are imported and merged with the new key
+	data, _ := loadFilter(t, users).(*bloom.BloomFilter).MarshalBinary()
+	if _, err := users.MergeFrom(bytes.NewReader(data), false); err != nil {
+		t.Fatalf("Failed to merge an export of the rekeyed filter: %v", err)
+	}
+	if _, err := users.Import(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Failed to import an export of the rekeyed filter: %v", err)
+	}
+	old, _ := bloom.New(params, bloom.WithKey([]byte("old"))).MarshalBinary()
+	if _, err := users.Import(bytes.NewReader(old)); !errors.Is(err, bloom.ErrKeyMismatch) {
+		t.Fatalf("Expected ErrKeyMismatch importing a filter with the old key, got %v", err)
+	}
+
+	// other filters of the registry keep the old key
+	other, _ := tenant.Create("other", params)
+	if _, err := other.Import(bytes.NewReader(old)); err != nil {
+		t.Fatalf("Failed to import into a filter with the old key: %v", err)
+	}
+
+	counting := params
+	counting.Type = bloom.TypeCounting
+	counters, _ := tenant.Create("counters", counting)
+	if err := counters.Rekey([]byte("new"), source); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Expected ErrUnsupported rekeying a counting filter, got %v", err)
+	}
+}
+
+func TestEntryRekeyMemoryLimit(t *testing.T) {
+	params := bloom.CalculateOptimalParameters(10_000, 0.01)
+	footprint, _ := bloom.Footprint(params)
+	r := New()
+	r.SetMemoryLimit(footprint * 3 / 2)
+	users, _ := defaultTenant(t, r).Create("users", params)
+
+	// the new bits are built next to the old ones
+	err := users.Rekey([]byte("new"), func(add func(item string)) error { return nil })
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("Expected ErrMemoryLimit rebuilding past the limit, got %v", err)
+	}
+	if usage := r.Memory(""); usage.Used != footprint {
+		t.Fatalf("Expected the booking of a failed rekey to be released, got %d bytes used", usage.Used)
+	}
+}
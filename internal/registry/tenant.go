@@ -0,0 +1,412 @@
+// Copyright 2025 Vinit Chauhan
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+// TenantLimits are the limits every tenant is held to, so one tenant's bulk
+// load cannot starve the others. Zero means no limit.
+type TenantLimits struct {
+	// Number of filters a tenant may hold
+	MaxFilters int `json:"max_filters"`
+	// Bits of memory the filters of a tenant may hold, as booked against
+	// the memory limit
+	MaxBits uint64 `json:"max_bits"`
+	// Item operations per second of a tenant, a batch counts every item
+	OpsPerSecond float64 `json:"ops_per_second"`
+}
+
+// TenantStatistics are the runtime statistics of a tenant
+type TenantStatistics struct {
+	// Name of the tenant
+	Tenant string `json:"tenant"`
+	// Number of filters the tenant holds
+	Filters int `json:"filters"`
+	// Bits of memory booked by the filters of the tenant
+	Bits uint64 `json:"bits"`
+	// Number of item operations allowed
+	Ops uint64 `json:"ops"`
+	// Number of item operations refused by the rate limit
+	ThrottledOps uint64 `json:"throttled_ops"`
+	// Limits the tenant is held to
+	Limits TenantLimits `json:"limits"`
+}
+
+// Tenant is a namespace of filters in a Registry, with its own quota and
+// rate limit. Filters of different tenants may share a name.
+type Tenant struct {
+	// name of the tenant
+	id string
+	// registry the tenant belongs to
+	registry *Registry
+
+	// token bucket of the rate limit, holding one second of operations
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	// statistics for the tenant
+	ops       atomic.Uint64
+	throttled atomic.Uint64
+
+	// memory and filters booked for filters being allocated, guarded by the
+	// registry's mutex, see Registry.book
+	reserved uint64
+	creating int
+}
+
+// Tenant Returns the tenant named id, creating it on first use
+// parameters:
+//
+//	id	: name of the tenant, letters, digits, '_', '.' and '-'
+//
+// returns:
+//
+//	*Tenant	: the tenant
+//	error	: ErrInvalidTenant if id cannot be used as a name
+func (r *Registry) Tenant(id string) (*Tenant, error) {
+	r.mu.RLock()
+	t, ok := r.tenants[id]
+	r.mu.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	if !validName.MatchString(id) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidTenant, id)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, ok := r.tenants[id]; ok {
+		return t, nil
+	}
+	t = &Tenant{id: id, registry: r}
+	r.tenants[id] = t
+	return t, nil
+}
+
+// SetTenantLimits Sets the limits every tenant is held to. Filters that
+// already exist are kept even if they exceed them.
+// parameters:
+//
+//	limits	: limits of every tenant
+//
+// returns:
+//
+//	none
+func (r *Registry) SetTenantLimits(limits TenantLimits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tenantLimits = limits
+}
+
+// SetDefaultParameters Sets the parameters the default filter of a tenant
+// is created with on first use, see Tenant.Default
+// parameters:
+//
+//	params	: parameters of the default filter of every tenant
+//
+// returns:
+//
+//	none
+func (r *Registry) SetDefaultParameters(params bloom.Parameters) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaults = &params
+}
+
+// ID Returns the name of the tenant
+func (t *Tenant) ID() string {
+	return t.id
+}
+
+// Create Creates a new, empty filter of the tenant and registers it under
+// name. The filter is only allocated if its footprint fits into the memory
+// limit and the quota of the tenant.
+// parameters:
+//
+//	name	: name of the filter, letters, digits, '_', '.' and '-'
+//	params	: parameters of the filter
+//
+// returns:
+//
+//	*Entry	: the registered filter
+//	error	: ErrInvalidName, ErrExists if the name is taken by a filter or
+//			  an alias, ErrQuota, ErrTooLarge, ErrMemoryLimit or an error
+//			  if the parameters are invalid
+func (t *Tenant) Create(name string, params bloom.Parameters) (*Entry, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidName, name)
+	}
+	footprint, err := bloom.Footprint(params)
+	if err != nil {
+		return nil, err
+	}
+
+	r := t.registry
+	key := entryKey{tenant: t.id, name: name}
+	r.mu.Lock()
+	if err := r.taken(key); err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	if err := r.fits(t, footprint, nil); err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	r.book(t, footprint, nil)
+	r.mu.Unlock()
+
+	// allocate without the lock, the registry is not held up by a large
+	// filter
+	entry := &Entry{
+		name:      name,
+		created:   time.Now(),
+		opts:      r.opts,
+		tenant:    t,
+		footprint: footprint,
+	}
+	filter, err := entry.newFilter(params)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.release(t, footprint, nil)
+	if err != nil {
+		return nil, err
+	}
+	// the name may have been taken in the meantime
+	if err := r.taken(key); err != nil {
+		return nil, err
+	}
+	entry.filter = filter
+	entry.touch()
+	r.entries[key] = entry
+	return entry, nil
+}
+
+// taken Checks that no filter or alias is registered under key, the caller
+// holds the lock
+func (r *Registry) taken(key entryKey) error {
+	if _, ok := r.entries[key]; ok {
+		return fmt.Errorf("%w: %s", ErrExists, key.name)
+	}
+	if _, ok := r.aliases[key]; ok {
+		return fmt.Errorf("%w: %s is an alias", ErrExists, key.name)
+	}
+	return nil
+}
+
+// Get Returns the filter of the tenant registered under name, or the filter
+// name is an alias of
+// parameters:
+//
+//	name	: name of the filter or alias
+//
+// returns:
+//
+//	*Entry	: the registered filter
+//	error	: ErrNotFound if there is no such filter
+func (t *Tenant) Get(name string) (*Entry, error) {
+	r := t.registry
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key := entryKey{tenant: t.id, name: name}
+	entry, ok := r.entries[key]
+	if !ok {
+		if target, aliased := r.aliases[key]; aliased {
+			entry, ok = r.entries[entryKey{tenant: t.id, name: target}]
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return entry, nil
+}
+
+// Default Returns the default filter of the tenant, the filter the unnamed
+// routes use. If the tenant has none it is created with the parameters set
+// by SetDefaultParameters, counted against the quota of the tenant like any
+// other filter.
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	*Entry	: the default filter
+//	error	: ErrNotFound if there is none and no default parameters are
+//			  set, or the error of Create
+func (t *Tenant) Default() (*Entry, error) {
+	entry, err := t.Get(DefaultName)
+	if !errors.Is(err, ErrNotFound) {
+		return entry, err
+	}
+
+	t.registry.mu.RLock()
+	defaults := t.registry.defaults
+	t.registry.mu.RUnlock()
+	if defaults == nil {
+		return nil, err
+	}
+
+	entry, err = t.Create(DefaultName, *defaults)
+	if errors.Is(err, ErrExists) {
+		// created by a concurrent request
+		return t.Get(DefaultName)
+	}
+	return entry, err
+}
+
+// Delete Unregisters the filter of the tenant registered under name.
+// Requests already holding it finish against it, its memory is released
+// after they do.
+// parameters:
+//
+//	name	: name of the filter
+//
+// returns:
+//
+//	error	: ErrNotFound if there is no such filter, ErrAliased while an
+//			  alias points at it, ErrDefault for the default filter of the
+//			  default tenant
+func (t *Tenant) Delete(name string) error {
+	if t.id == DefaultTenant && name == DefaultName {
+		return ErrDefault
+	}
+
+	entry, err := t.unregister(name)
+	if err != nil {
+		return err
+	}
+	// a hibernated filter is not reloaded, only its snapshot removed
+	entry.discard()
+	return nil
+}
+
+// unregister Removes the filter registered under name from the registry
+func (t *Tenant) unregister(name string) (*Entry, error) {
+	r := t.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := entryKey{tenant: t.id, name: name}
+	entry, ok := r.entries[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	for alias, target := range r.aliases {
+		if alias.tenant == t.id && target == name {
+			return nil, fmt.Errorf("%w: %s is the target of %s", ErrAliased, name, alias.name)
+		}
+	}
+	delete(r.entries, key)
+	return entry, nil
+}
+
+// List Returns every filter of the tenant, ordered by name
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	[]*Entry	: the filters of the tenant
+func (t *Tenant) List() []*Entry {
+	return t.registry.list(t.id)
+}
+
+// Allow Takes ops item operations from the rate limit of the tenant. A
+// batch larger than a second of operations is allowed whenever the bucket
+// is not empty and paid back before the next one, so the rate holds on
+// average. The bucket holds at least one operation, so a rate below one per
+// second still lets an operation through every 1/rate seconds.
+// parameters:
+//
+//	ops	: number of item operations, e.g. the items of a batch
+//
+// returns:
+//
+//	bool			: whether the operations may go ahead
+//	time.Duration	: time until the tenant may retry if they may not
+func (t *Tenant) Allow(ops int) (bool, time.Duration) {
+	t.registry.mu.RLock()
+	rate := t.registry.tenantLimits.OpsPerSecond
+	t.registry.mu.RUnlock()
+
+	if rate <= 0 {
+		t.ops.Add(uint64(ops))
+		return true, 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	burst := max(rate, 1)
+	now := time.Now()
+	if t.last.IsZero() {
+		t.tokens = burst
+	} else {
+		t.tokens = min(burst, t.tokens+now.Sub(t.last).Seconds()*rate)
+	}
+	t.last = now
+
+	if t.tokens < 1 {
+		t.throttled.Add(uint64(ops))
+		return false, time.Duration((1 - t.tokens) / rate * float64(time.Second))
+	}
+	t.tokens -= float64(ops)
+	t.ops.Add(uint64(ops))
+	return true, 0
+}
+
+// Statistics Returns the runtime statistics of the tenant
+// parameters:
+//
+//	none
+//
+// returns:
+//
+//	TenantStatistics	: filters, memory and operations of the tenant
+func (t *Tenant) Statistics() TenantStatistics {
+	t.registry.mu.RLock()
+	limits := t.registry.tenantLimits
+	t.registry.mu.RUnlock()
+
+	stats := TenantStatistics{
+		Tenant:       t.id,
+		Ops:          t.ops.Load(),
+		ThrottledOps: t.throttled.Load(),
+		Limits:       limits,
+	}
+	for _, entry := range t.List() {
+		stats.Filters++
+		stats.Bits += entry.booked() * 8
+	}
+	return stats
+}
@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestTenants(t *testing.T) {
+	r := New()
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+
+	a, err := r.Tenant("team-a")
+	if err != nil {
+		t.Fatalf("Failed to get tenant: %v", err)
+	}
+	b, _ := r.Tenant("team-b")
+	if again, _ := r.Tenant("team-a"); again != a {
+		t.Fatalf("Expected the same tenant for the same name")
+	}
+	if _, err := r.Tenant("a/b"); !errors.Is(err, ErrInvalidTenant) {
+		t.Fatalf("Expected ErrInvalidTenant, got %v", err)
+	}
+
+	// tenants may share filter names without sharing filters
+	users, err := a.Create("users", params)
+	if err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+	if _, err := b.Create("users", params); err != nil {
+		t.Fatalf("Expected a name of another tenant to be free, got %v", err)
+	}
+	loadFilter(t, users).Add("apple")
+	other, _ := b.Get("users")
+	if loadFilter(t, other).Exists("apple") {
+		t.Fatalf("Expected an item of one tenant to be absent from another")
+	}
+	if entry, _ := a.Get("users"); entry.Tenant() != a {
+		t.Fatalf("Expected the filter to belong to its tenant")
+	}
+
+	if list := a.List(); len(list) != 1 || list[0] != users {
+		t.Fatalf("Expected tenant a to list only its own filter, got %v", list)
+	}
+	if err := a.Delete("users"); err != nil {
+		t.Fatalf("Failed to delete filter: %v", err)
+	}
+	if _, err := b.Get("users"); err != nil {
+		t.Fatalf("Expected the filter of another tenant to survive, got %v", err)
+	}
+
+	// only the default filter of the default tenant is protected
+	if _, err := a.Create(DefaultName, params); err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+	if err := a.Delete(DefaultName); err != nil {
+		t.Fatalf("Expected a tenant's own default filter to be deletable, got %v", err)
+	}
+}
+
+func TestTenantDefault(t *testing.T) {
+	r := New()
+	r.SetTenantLimits(TenantLimits{MaxFilters: 1})
+	a, _ := r.Tenant("team-a")
+	if _, err := a.Default(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound without default parameters, got %v", err)
+	}
+
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+	r.SetDefaultParameters(params)
+	entry, err := a.Default()
+	if err != nil {
+		t.Fatalf("Failed to create the default filter: %v", err)
+	}
+	if entry.Name() != DefaultName || entry.Tenant() != a {
+		t.Fatalf("Expected the default filter of the tenant, got %s", entry.Name())
+	}
+	if again, _ := a.Default(); again != entry {
+		t.Fatalf("Expected the default filter to be created once")
+	}
+
+	// it counts against the quota like any other filter
+	b, _ := r.Tenant("team-b")
+	b.Create("users", params)
+	if _, err := b.Default(); !errors.Is(err, ErrQuota) {
+		t.Fatalf("Expected ErrQuota past the filter quota, got %v", err)
+	}
+}
+
+func TestTenantQuota(t *testing.T) {
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+	footprint, _ := bloom.Footprint(params)
+
+	r := New()
+	r.SetTenantLimits(TenantLimits{MaxFilters: 2})
+	a, _ := r.Tenant("team-a")
+	b, _ := r.Tenant("team-b")
+	for _, name := range []string{"one", "two"} {
+		if _, err := a.Create(name, params); err != nil {
+			t.Fatalf("Failed to create a filter within the quota: %v", err)
+		}
+	}
+	if _, err := a.Create("three", params); !errors.Is(err, ErrQuota) {
+		t.Fatalf("Expected ErrQuota past the filter quota, got %v", err)
+	}
+	if _, err := b.Create("one", params); err != nil {
+		t.Fatalf("Expected the quota to hold per tenant, got %v", err)
+	}
+
+	// a filter replaced in place does not count twice
+	one, _ := a.Get("one")
+	if _, err := one.Recreate(params); err != nil {
+		t.Fatalf("Failed to recreate a filter in place: %v", err)
+	}
+
+	r.SetTenantLimits(TenantLimits{MaxBits: 8 * (footprint + footprint/2)})
+	c, _ := r.Tenant("team-c")
+	if _, err := c.Create("one", params); err != nil {
+		t.Fatalf("Failed to create a filter within the quota: %v", err)
+	}
+	if _, err := c.Create("two", params); !errors.Is(err, ErrQuota) {
+		t.Fatalf("Expected ErrQuota past the memory quota, got %v", err)
+	}
+
+	stats := c.Statistics()
+	if stats.Tenant != "team-c" || stats.Filters != 1 || stats.Bits != 8*footprint {
+		t.Fatalf("Expected one filter of %d bits, got %+v", 8*footprint, stats)
+	}
+}
+
+func TestTenantRateLimit(t *testing.T) {
+	r := New()
+	a, _ := r.Tenant("team-a")
+	if ok, _ := a.Allow(1_000_000); !ok {
+		t.Fatalf("Expected no rate limit by default")
+	}
+
+	r.SetTenantLimits(TenantLimits{OpsPerSecond: 10})
+	b, _ := r.Tenant("team-b")
+	for i := range 10 {
+		if ok, _ := b.Allow(1); !ok {
+			t.Fatalf("Expected operation %d within the rate to be allowed", i)
+		}
+	}
+	ok, retry := b.Allow(1)
+	if ok || retry <= 0 {
+		t.Fatalf("Expected the operation past the rate to be throttled, got %v after %v", ok, retry)
+	}
+
+	// a large batch is allowed once and paid back afterwards
+	c, _ := r.Tenant("team-c")
+	if ok, _ := c.Allow(100); !ok {
+		t.Fatalf("Expected a batch to be allowed on a full bucket")
+	}
+	if ok, retry := c.Allow(1); ok || retry < 9*time.Second {
+		t.Fatalf("Expected the batch to be paid back, got %v after %v", ok, retry)
+	}
+
+	stats := b.Statistics()
+	if stats.Ops != 10 || stats.ThrottledOps != 1 || stats.Limits.OpsPerSecond != 10 {
+		t.Fatalf("Expected 10 allowed and 1 throttled operation, got %+v", stats)
+	}
+
+	// a rate below one per second still allows an operation
+	r.SetTenantLimits(TenantLimits{OpsPerSecond: 0.5})
+	d, _ := r.Tenant("team-d")
+	if ok, _ := d.Allow(1); !ok {
+		t.Fatalf("Expected an operation to be allowed at a rate below one per second")
+	}
+	if ok, retry := d.Allow(1); ok || retry > 2*time.Second {
+		t.Fatalf("Expected the next operation within 2s, got %v after %v", ok, retry)
+	}
+}
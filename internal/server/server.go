@@ -16,19 +16,28 @@ package server
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/vinit-chauhan/go-bloomservice/internal/api"
 	api_v1 "github.com/vinit-chauhan/go-bloomservice/internal/api/v1"
 )
 
+// BodyLimit is the largest request body accepted, in bytes. It bounds the
+// size of imported and merged filters.
+var BodyLimit = 64 << 20
+
 func StartServer() *fiber.App {
 	app := fiber.New(
 		fiber.Config{
 			DisableStartupMessage: true,
 			StrictRouting:         true,
 			CaseSensitive:         true,
+			BodyLimit:             BodyLimit,
 		},
 	)
 
+	// a panic in one handler fails that request rather than the service
+	app.Use(recover.New())
+
 	app.Route("/", api.HealthRouter)
 	app.Route("/api/v1", api_v1.BloomRouter)
 
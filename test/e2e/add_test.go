@@ -0,0 +1,37 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func doAddIfAbsent(t *testing.T, item string) (int, map[string]any) {
+	t.Helper()
+	return doJSONRequest(t, http.MethodPost, getEndpoint(OpInsert), map[string]any{"item": item, "if_absent": true})
+}
+
+func TestAddIfAbsent(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+
+	steps := []struct {
+		Name               string
+		ExpectedStatusCode int
+		AlreadyPresent     bool
+	}{
+		{"First add", http.StatusCreated, false},
+		{"Duplicate add", http.StatusOK, true},
+	}
+	for _, step := range steps {
+		status, result := doAddIfAbsent(t, "event-1")
+		if status != step.ExpectedStatusCode || result["already_present"] != step.AlreadyPresent {
+			t.Errorf("Step %q: expected %d and already_present=%v, got %d: %v", step.Name, step.ExpectedStatusCode, step.AlreadyPresent, status, result)
+		}
+	}
+
+	useFilter(t, bloom.TypeCuckoo)
+	if status, _ := doAddIfAbsent(t, "event-1"); status != http.StatusMethodNotAllowed {
+		t.Errorf("Cuckoo filter: expected %d, got %d", http.StatusMethodNotAllowed, status)
+	}
+}
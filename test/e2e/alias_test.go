@@ -0,0 +1,87 @@
+package e2e
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestAliases(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	filters := "/api/" + TestAPIVersion + "/filters"
+	aliases := "/api/" + TestAPIVersion + "/aliases"
+	current := filters + "/users-current"
+	item := map[string]any{"item": "apple"}
+
+	steps := []struct {
+		Name               string
+		Method             string
+		Endpoint           string
+		Body               any
+		ExpectedStatusCode int
+	}{
+		{"Create the live filter", http.MethodPost, filters, map[string]any{"name": "users-2026-09", "capacity": 1000, "false_positive_rate": 0.01}, http.StatusCreated},
+		{"Alias an unknown filter", http.MethodPut, aliases + "/users-current", map[string]any{"filter": "missing"}, http.StatusNotFound},
+		{"Alias the live filter", http.MethodPut, aliases + "/users-current", map[string]any{"filter": "users-2026-09"}, http.StatusCreated},
+		{"Insert through the alias", http.MethodPost, current + "/add", item, http.StatusCreated},
+		{"Lookup in the live filter", http.MethodPost, filters + "/users-2026-09/exists", item, http.StatusOK},
+		{"Create the rebuilt filter", http.MethodPost, filters, map[string]any{"name": "users-2026-10", "capacity": 100000, "false_positive_rate": 0.001}, http.StatusCreated},
+		{"Lookup before the swap", http.MethodPost, current + "/exists", item, http.StatusOK},
+		{"Swap the alias", http.MethodPut, aliases + "/users-current", map[string]any{"filter": "users-2026-10"}, http.StatusOK},
+		{"Lookup after the swap", http.MethodPost, current + "/exists", item, http.StatusNotFound},
+		{"Retire the old filter", http.MethodDelete, filters + "/users-2026-09", nil, http.StatusOK},
+		{"Delete an aliased filter", http.MethodDelete, filters + "/users-2026-10", nil, http.StatusConflict},
+		{"Alias named like a filter", http.MethodPut, aliases + "/users-2026-10", map[string]any{"filter": "users-2026-10"}, http.StatusConflict},
+		{"Delete the alias", http.MethodDelete, aliases + "/users-current", nil, http.StatusOK},
+		{"Use a deleted alias", http.MethodPost, current + "/exists", item, http.StatusNotFound},
+		{"Delete an unknown alias", http.MethodDelete, aliases + "/users-current", nil, http.StatusNotFound},
+	}
+	for _, step := range steps {
+		if status, result := doJSONRequest(t, step.Method, step.Endpoint, step.Body); status != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d: %v", step.Name, step.ExpectedStatusCode, status, result)
+		}
+	}
+}
+
+func TestAliasSwapUnderTraffic(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	filters := "/api/" + TestAPIVersion + "/filters"
+	alias := "/api/" + TestAPIVersion + "/aliases/users-current"
+	names := []string{"users-blue", "users-green"}
+	for _, name := range names {
+		create := map[string]any{"name": name, "capacity": 1000, "false_positive_rate": 0.01}
+		if status, result := doJSONRequest(t, http.MethodPost, filters, create); status != http.StatusCreated {
+			t.Fatalf("Expected %d, got %d: %v", http.StatusCreated, status, result)
+		}
+		doJSONRequest(t, http.MethodPost, filters+"/"+name+"/add", map[string]any{"item": "apple"})
+	}
+	doJSONRequest(t, http.MethodPut, alias, map[string]any{"filter": names[0]})
+
+	// every lookup is served by one of the filters while the alias flips
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				resp, err := sendRequest(http.MethodPost, filters+"/users-current/exists", nil, map[string]any{"item": "apple"})
+				if err != nil {
+					t.Errorf("Failed request during the swap: %v", err)
+					return
+				}
+				if resp.status != http.StatusOK {
+					t.Errorf("Expected %d during the swap, got %d: %s", http.StatusOK, resp.status, resp.body)
+					return
+				}
+			}
+		}()
+	}
+	for i := range 50 {
+		if status, result := doJSONRequest(t, http.MethodPut, alias, map[string]any{"filter": names[i%2]}); status != http.StatusOK {
+			t.Errorf("Expected %d swapping the alias, got %d: %v", http.StatusOK, status, result)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,62 @@
+package e2e
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/api/v1/handlers"
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestBatch(t *testing.T) {
+	addBatch := "/api/" + TestAPIVersion + "/add/batch"
+	existsBatch := "/api/" + TestAPIVersion + "/exists/batch"
+
+	for _, filterType := range []bloom.FilterType{bloom.TypeStandard, bloom.TypeCuckoo} {
+		useFilter(t, filterType)
+
+		status, result := doJSONRequest(t, http.MethodPost, addBatch, map[string]any{"items": []string{"a", "b", "c"}})
+		if status != http.StatusCreated {
+			t.Fatalf("%s: expected %d, got %d: %v", filterType, http.StatusCreated, status, result)
+		}
+
+		status, result = doJSONRequest(t, http.MethodPost, existsBatch, map[string]any{"items": []string{"a", "missing", "c"}})
+		if status != http.StatusOK {
+			t.Fatalf("%s: expected %d, got %d: %v", filterType, http.StatusOK, status, result)
+		}
+		exists, _ := result["exists"].([]any)
+		if len(exists) != 3 || exists[0] != true || exists[1] != false || exists[2] != true {
+			t.Fatalf("%s: expected results in request order, got %v", filterType, result)
+		}
+	}
+}
+
+func TestBatchRefused(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	addBatch := "/api/" + TestAPIVersion + "/add/batch"
+
+	previous := handlers.MaxBatchSize
+	handlers.MaxBatchSize = 10
+	t.Cleanup(func() { handlers.MaxBatchSize = previous })
+
+	tooMany := make([]string, 11)
+	for i := range tooMany {
+		tooMany[i] = "item-" + strconv.Itoa(i)
+	}
+
+	tests := []struct {
+		Name               string
+		Items              []string
+		ExpectedStatusCode int
+	}{
+		{"Empty batch", nil, http.StatusBadRequest},
+		{"Empty item", []string{"a", ""}, http.StatusBadRequest},
+		{"Too many items", tooMany, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range tests {
+		if status, result := doJSONRequest(t, http.MethodPost, addBatch, map[string]any{"items": tc.Items}); status != tc.ExpectedStatusCode {
+			t.Errorf("%s: expected %d, got %d: %v", tc.Name, tc.ExpectedStatusCode, status, result)
+		}
+	}
+}
@@ -73,7 +73,7 @@ func TestBloomE2EGrouped(t *testing.T) {
 	for _, scenario := range scenarios {
 		scenario := scenario // capture
 		t.Run(scenario.Name, func(t *testing.T) {
-			bloom.Init(10000, 0.01) // fresh filter per scenario
+			useFilter(t, bloom.TypeStandard) // fresh filter per scenario
 			app := server.StartServer()
 
 			for _, step := range scenario.Steps {
@@ -0,0 +1,42 @@
+package e2e
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestBinaryItems(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	raw := []byte{0xde, 0xad, 0x00, 0xbe, 0xef}
+	encoded := base64.StdEncoding.EncodeToString(raw)
+
+	steps := []struct {
+		Name               string
+		Endpoint           string
+		Body               map[string]any
+		ExpectedStatusCode int
+	}{
+		{"Add base64", getEndpoint(OpInsert), map[string]any{"item": encoded, "encoding": "base64"}, http.StatusCreated},
+		{"Lookup hex", getEndpoint(OpLookup), map[string]any{"item": hex.EncodeToString(raw), "encoding": "hex"}, http.StatusOK},
+		{"Lookup undecoded", getEndpoint(OpLookup), map[string]any{"item": encoded}, http.StatusNotFound},
+		{"Invalid base64", getEndpoint(OpLookup), map[string]any{"item": "not base64!", "encoding": "base64"}, http.StatusBadRequest},
+		{"Invalid hex", getEndpoint(OpInsert), map[string]any{"item": "xyz", "encoding": "hex"}, http.StatusBadRequest},
+		{"Unknown encoding", getEndpoint(OpInsert), map[string]any{"item": "abc", "encoding": "base32"}, http.StatusBadRequest},
+		{"Batch hex", "/api/" + TestAPIVersion + "/exists/batch", map[string]any{"items": []string{hex.EncodeToString(raw)}, "encoding": "hex"}, http.StatusOK},
+		{"Batch invalid hex", "/api/" + TestAPIVersion + "/exists/batch", map[string]any{"items": []string{"zz"}, "encoding": "hex"}, http.StatusBadRequest},
+	}
+	for _, step := range steps {
+		if got := doRequest(t, http.MethodPost, step.Endpoint, nil, step.Body); got.status != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d: %s", step.Name, step.ExpectedStatusCode, got.status, got.body)
+		}
+	}
+
+	// binary keys map to the same bits as their raw bytes
+	if binary, ok := defaultFilter(t).(bloom.BytesFilter); !ok || !binary.ExistsBytes(raw) {
+		t.Fatalf("Expected the raw bytes to exist in the filter")
+	}
+}
@@ -0,0 +1,90 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+	"github.com/vinit-chauhan/go-bloomservice/internal/server"
+)
+
+func TestCreateCuckooFilter(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	app := server.StartServer()
+
+	body, _ := json.Marshal(map[string]any{
+		"capacity":            64,
+		"false_positive_rate": 0.01,
+		"type":                "cuckoo",
+		"max_kicks":           20,
+	})
+	req := httptest.NewRequest(http.MethodPut, "/api/"+TestAPIVersion+"/filter", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("Expected %d, got %d: %s", http.StatusCreated, resp.StatusCode, body)
+	}
+	if got := defaultFilter(t).GetParameters().Type; got != bloom.TypeCuckoo {
+		t.Fatalf("Expected a cuckoo filter, got %s", got)
+	}
+
+	items := "/api/" + TestAPIVersion + "/items"
+	steps := []struct {
+		Name               string
+		Method             string
+		Endpoint           string
+		ExpectedStatusCode int
+	}{
+		{"Insert hello", http.MethodPost, getEndpoint(OpInsert), http.StatusCreated},
+		{"Lookup hello", http.MethodPost, getEndpoint(OpLookup), http.StatusOK},
+		{"Remove hello", http.MethodDelete, items, http.StatusOK},
+		{"Lookup hello after removal", http.MethodPost, getEndpoint(OpLookup), http.StatusNotFound},
+	}
+	for _, step := range steps {
+		if got := doItemRequest(t, step.Method, step.Endpoint, "hello"); got != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d", step.Name, step.ExpectedStatusCode, got)
+		}
+	}
+
+	// fill the filter until inserts are refused
+	status := http.StatusCreated
+	for i := 0; i < 1000 && status == http.StatusCreated; i++ {
+		status = doItemRequest(t, http.MethodPost, getEndpoint(OpInsert), "item-"+strconv.Itoa(i))
+	}
+	if status != http.StatusInsufficientStorage {
+		t.Fatalf("Expected a full filter to return %d, got %d", http.StatusInsufficientStorage, status)
+	}
+}
+
+func TestCreateFilterInvalid(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	app := server.StartServer()
+
+	for _, body := range []map[string]any{
+		{"capacity": 0, "false_positive_rate": 0.01},
+		{"capacity": 100, "false_positive_rate": 1.5},
+		{"capacity": 100, "false_positive_rate": 0.01, "type": "quotient"},
+	} {
+		raw, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPut, "/api/"+TestAPIVersion+"/filter", bytes.NewReader(raw))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Body %v: expected %d, got %d", body, http.StatusBadRequest, resp.StatusCode)
+		}
+	}
+}
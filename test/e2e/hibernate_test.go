@@ -0,0 +1,48 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/api/v1/handlers"
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestHibernation(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	if err := handlers.Filters.SetHibernation(t.TempDir()); err != nil {
+		t.Fatalf("Failed to set up hibernation: %v", err)
+	}
+	users := "/api/" + TestAPIVersion + "/filters/users"
+	item := map[string]any{"item": "apple"}
+
+	doJSONRequest(t, http.MethodPost, "/api/"+TestAPIVersion+"/filters", map[string]any{"name": "users", "capacity": 1000, "false_positive_rate": 0.01})
+	doJSONRequest(t, http.MethodPost, users+"/add", item)
+	if n, err := handlers.Filters.Hibernate(time.Now()); err != nil || n != 2 {
+		t.Fatalf("Expected both filters to hibernate, got %d, %v", n, err)
+	}
+
+	// describing a filter does not reload it
+	for range 2 {
+		status, result := doJSONRequest(t, http.MethodGet, users, nil)
+		if status != http.StatusOK || result["hibernated"] != true || result["size_bytes"].(float64) != 0 || result["last_access"] == nil {
+			t.Fatalf("Expected a hibernated filter, got %d: %v", status, result)
+		}
+	}
+
+	if status, result := doJSONRequest(t, http.MethodPost, users+"/exists", item); status != http.StatusOK {
+		t.Fatalf("Expected the reloaded filter to hold its items, got %d: %v", status, result)
+	}
+	status, result := doJSONRequest(t, http.MethodGet, users, nil)
+	if status != http.StatusOK || result["hibernated"] != false || result["size_bytes"].(float64) == 0 {
+		t.Fatalf("Expected the filter back in memory, got %d: %v", status, result)
+	}
+
+	status, result = doJSONRequest(t, http.MethodGet, "/api/"+TestAPIVersion+"/memory", nil)
+	for _, f := range result["filters"].([]any) {
+		if f := f.(map[string]any); f["name"] == "default" && f["hibernated"] != true {
+			t.Fatalf("Expected the unused default filter to stay hibernated, got %d: %v", status, f)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/api/v1/handlers"
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestMemoryLimit(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	used := defaultFilter(t).SizeInBytes()
+	handlers.Filters.SetMemoryLimit(4 * used)
+	filters := "/api/" + TestAPIVersion + "/filters"
+
+	steps := []struct {
+		Name               string
+		Method             string
+		Endpoint           string
+		Body               any
+		ExpectedStatusCode int
+	}{
+		{"Fits", http.MethodPost, filters, map[string]any{"name": "a", "capacity": 20000, "false_positive_rate": 0.01}, http.StatusCreated},
+		{"Does not fit next to the others", http.MethodPost, filters, map[string]any{"name": "b", "capacity": 20000, "false_positive_rate": 0.01}, http.StatusInsufficientStorage},
+		{"Larger than the limit", http.MethodPost, filters, map[string]any{"name": "c", "capacity": 1e10, "false_positive_rate": 1e-9}, http.StatusRequestEntityTooLarge},
+		{"Replacement too large", http.MethodPut, filters + "/a", map[string]any{"capacity": 40000, "false_positive_rate": 0.01}, http.StatusInsufficientStorage},
+		{"Replacement in place", http.MethodPut, filters + "/a", map[string]any{"capacity": 20000, "false_positive_rate": 0.01}, http.StatusCreated},
+	}
+	for _, step := range steps {
+		if status, result := doJSONRequest(t, step.Method, step.Endpoint, step.Body); status != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d: %v", step.Name, step.ExpectedStatusCode, status, result)
+		}
+	}
+
+	status, result := doJSONRequest(t, http.MethodGet, "/api/"+TestAPIVersion+"/memory", nil)
+	if status != http.StatusOK {
+		t.Fatalf("Expected %d, got %d: %v", http.StatusOK, status, result)
+	}
+	if limit := result["limit_bytes"].(float64); limit != float64(4*used) {
+		t.Fatalf("Expected a limit of %d bytes, got %v", 4*used, limit)
+	}
+	list := result["filters"].([]any)
+	if len(list) != 2 {
+		t.Fatalf("Expected the default filter and filter a, got %v", list)
+	}
+	var total float64
+	for _, f := range list {
+		total += f.(map[string]any)["booked_bytes"].(float64)
+	}
+	if total != result["used_bytes"].(float64) {
+		t.Fatalf("Expected used_bytes to add up the filters, got %v", result)
+	}
+}
+
+func TestScalableMemoryLimit(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	handlers.Filters.SetMemoryLimit(4 * defaultFilter(t).SizeInBytes())
+	filters := "/api/" + TestAPIVersion + "/filters"
+
+	create := map[string]any{"name": "growing", "type": "scalable", "capacity": 1000, "false_positive_rate": 0.01}
+	if status, result := doJSONRequest(t, http.MethodPost, filters, create); status != http.StatusCreated {
+		t.Fatalf("Expected %d, got %d: %v", http.StatusCreated, status, result)
+	}
+
+	// the filter grows until its next layer no longer fits
+	status := http.StatusCreated
+	for batch := 0; batch < 100 && status == http.StatusCreated; batch++ {
+		items := make([]string, 1000)
+		for i := range items {
+			items[i] = strconv.Itoa(batch*len(items) + i)
+		}
+		status, _ = doJSONRequest(t, http.MethodPost, filters+"/growing/add/batch", map[string]any{"items": items})
+	}
+	if status != http.StatusInsufficientStorage {
+		t.Fatalf("Expected %d once the filter cannot grow, got %d", http.StatusInsufficientStorage, status)
+	}
+
+	_, result := doJSONRequest(t, http.MethodGet, "/api/"+TestAPIVersion+"/memory", nil)
+	if used, limit := result["used_bytes"].(float64), result["limit_bytes"].(float64); used > limit {
+		t.Fatalf("Expected the filters to stay within the limit, got %v of %v bytes", used, limit)
+	}
+}
@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func doMergeRequest(t *testing.T, body any) int {
+	t.Helper()
+	resp := doRequest(t, http.MethodPost, "/api/"+TestAPIVersion+"/merge", nil, body)
+	if resp.status >= http.StatusBadRequest {
+		t.Logf("Merge returned %d: %s", resp.status, resp.body)
+	}
+	return resp.status
+}
+
+func TestMergeUnion(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	params := defaultFilter(t).GetParameters()
+
+	// a filter built on another node
+	store := bloom.NewPackedBitStore(params.Size)
+	remote := bloom.NewWithStore(params, store)
+	remote.Add("remote-item")
+
+	lookup := getEndpoint(OpLookup)
+	if got := doItemRequest(t, http.MethodPost, lookup, "remote-item"); got != http.StatusNotFound {
+		t.Fatalf("Expected remote-item to be missing before the merge, got %d", got)
+	}
+
+	if got := doMergeRequest(t, map[string]any{"params": params, "bits": store.Bytes()}); got != http.StatusOK {
+		t.Fatalf("Expected %d, got %d", http.StatusOK, got)
+	}
+	if got := doItemRequest(t, http.MethodPost, lookup, "remote-item"); got != http.StatusOK {
+		t.Fatalf("Expected remote-item to exist after the merge, got %d", got)
+	}
+}
+
+func TestMergeRefused(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	params := defaultFilter(t).GetParameters()
+	bits := bloom.NewPackedBitStore(params.Size).Bytes()
+
+	seeded := params
+	seeded.Seed = params.Seed + 1
+
+	tests := []struct {
+		Name               string
+		Body               any
+		ExpectedStatusCode int
+	}{
+		{"Different seed", map[string]any{"params": seeded, "bits": bits}, http.StatusConflict},
+		{"Truncated bits", map[string]any{"params": params, "bits": bits[:8]}, http.StatusBadRequest},
+		{"Unknown operation", map[string]any{"operation": "xor", "params": params, "bits": bits}, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		if got := doMergeRequest(t, tc.Body); got != tc.ExpectedStatusCode {
+			t.Errorf("%s: expected %d, got %d", tc.Name, tc.ExpectedStatusCode, got)
+		}
+	}
+
+	useFilter(t, bloom.TypeCounting)
+	if got := doMergeRequest(t, map[string]any{"params": params, "bits": bits}); got != http.StatusConflict {
+		t.Errorf("Merge into a counting filter: expected %d, got %d", http.StatusConflict, got)
+	}
+}
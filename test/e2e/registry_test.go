@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestNamedFilters(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	filters := "/api/" + TestAPIVersion + "/filters"
+	team := filters + "/team-a"
+
+	create := map[string]any{"name": "team-a", "capacity": 1000, "false_positive_rate": 0.01, "type": "counting"}
+	if status, result := doJSONRequest(t, http.MethodPost, filters, create); status != http.StatusCreated {
+		t.Fatalf("Expected %d, got %d: %v", http.StatusCreated, status, result)
+	}
+
+	steps := []struct {
+		Name               string
+		Method             string
+		Endpoint           string
+		ExpectedStatusCode int
+	}{
+		{"Insert into the named filter", http.MethodPost, team + "/add", http.StatusCreated},
+		{"Lookup in the named filter", http.MethodPost, team + "/exists", http.StatusOK},
+		{"Lookup in the default filter", http.MethodPost, getEndpoint(OpLookup), http.StatusNotFound},
+		{"Remove from the named counting filter", http.MethodDelete, team + "/items", http.StatusOK},
+		{"Insert into the named filter again", http.MethodPost, team + "/add", http.StatusCreated},
+		{"Insert into an unknown filter", http.MethodPost, filters + "/missing/add", http.StatusNotFound},
+	}
+	for _, step := range steps {
+		if got := doItemRequest(t, step.Method, step.Endpoint, "hello"); got != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d", step.Name, step.ExpectedStatusCode, got)
+		}
+	}
+
+	status, result := doJSONRequest(t, http.MethodGet, team, nil)
+	if status != http.StatusOK {
+		t.Fatalf("Expected %d, got %d: %v", http.StatusOK, status, result)
+	}
+	if params := result["params"].(map[string]any); params["type"] != "counting" {
+		t.Fatalf("Expected a counting filter, got %v", params["type"])
+	}
+
+	status, result = doJSONRequest(t, http.MethodGet, filters, nil)
+	if list := result["filters"].([]any); status != http.StatusOK || len(list) != 2 {
+		t.Fatalf("Expected the default and the named filter, got %d: %v", status, result)
+	}
+
+	if status, _ := doJSONRequest(t, http.MethodDelete, team+"/reset", nil); status != http.StatusOK {
+		t.Fatalf("Expected %d resetting the named filter, got %d", http.StatusOK, status)
+	}
+	if got := doItemRequest(t, http.MethodPost, team+"/exists", "hello"); got != http.StatusNotFound {
+		t.Fatalf("Expected the reset filter to be empty, got %d", got)
+	}
+
+	if status, _ := doJSONRequest(t, http.MethodDelete, team, nil); status != http.StatusOK {
+		t.Fatalf("Expected %d deleting the filter, got %d", http.StatusOK, status)
+	}
+	if status, _ := doJSONRequest(t, http.MethodGet, team, nil); status != http.StatusNotFound {
+		t.Fatalf("Expected %d for a deleted filter, got %d", http.StatusNotFound, status)
+	}
+}
+
+func TestNamedFiltersRefused(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	filters := "/api/" + TestAPIVersion + "/filters"
+
+	steps := []struct {
+		Name               string
+		Method             string
+		Endpoint           string
+		Body               any
+		ExpectedStatusCode int
+	}{
+		{"Taken name", http.MethodPost, filters, map[string]any{"name": "default", "capacity": 1000, "false_positive_rate": 0.01}, http.StatusConflict},
+		{"Invalid name", http.MethodPost, filters, map[string]any{"name": "a b", "capacity": 1000, "false_positive_rate": 0.01}, http.StatusBadRequest},
+		{"Missing capacity", http.MethodPost, filters, map[string]any{"name": "team-b", "false_positive_rate": 0.01}, http.StatusBadRequest},
+		{"Delete the default filter", http.MethodDelete, filters + "/default", nil, http.StatusBadRequest},
+		{"Delete an unknown filter", http.MethodDelete, filters + "/missing", nil, http.StatusNotFound},
+	}
+	for _, step := range steps {
+		if status, result := doJSONRequest(t, step.Method, step.Endpoint, step.Body); status != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d: %v", step.Name, step.ExpectedStatusCode, status, result)
+		}
+	}
+}
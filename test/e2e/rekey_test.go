@@ -0,0 +1,65 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func TestRekey(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	base := "/api/" + TestAPIVersion
+	items := []string{"apple", "banana"}
+	status, _ := doJSONRequest(t, http.MethodPost, base+"/add/batch", map[string]any{"items": items})
+	if status != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, status)
+	}
+
+	status, result := doJSONRequest(t, http.MethodPost, base+"/rekey", map[string]any{
+		"key":   "0123456789abcdef",
+		"items": items,
+	})
+	if status != http.StatusOK || result["count"] != float64(len(items)) {
+		t.Fatalf("Expected status %d and %d items, got %d: %v", http.StatusOK, len(items), status, result)
+	}
+	if _, ok := result["key"]; ok {
+		t.Fatalf("Expected the key never to be returned, got %v", result)
+	}
+	for _, item := range items {
+		status, result := doJSONRequest(t, http.MethodPost, base+"/exists", map[string]any{"item": item})
+		if status != http.StatusOK || result["exists"] != true {
+			t.Fatalf("Expected item %s to exist after rekeying, got %d: %v", item, status, result)
+		}
+	}
+
+	// an export of the rekeyed filter is imported with the new key
+	resp := doRequest(t, http.MethodGet, base+"/export", nil, nil)
+	if resp.status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, resp.status, resp.body)
+	}
+	imported := doRequest(t, http.MethodPut, base+"/import", nil, resp.body)
+	if imported.status != http.StatusCreated {
+		t.Fatalf("Expected status %d importing the export, got %d: %s", http.StatusCreated, imported.status, imported.body)
+	}
+
+	// without a key a random one is generated
+	status, result = doJSONRequest(t, http.MethodPost, base+"/rekey", map[string]any{"items": items})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %v", http.StatusOK, status, result)
+	}
+
+	status, result = doJSONRequest(t, http.MethodPost, base+"/rekey", map[string]any{"key": "not hex"})
+	if status != http.StatusBadRequest {
+		t.Fatalf("Expected status %d for an invalid key, got %d: %v", http.StatusBadRequest, status, result)
+	}
+}
+
+func TestRekeyUnsupported(t *testing.T) {
+	useFilter(t, bloom.TypeCounting)
+
+	status, result := doJSONRequest(t, http.MethodPost, "/api/"+TestAPIVersion+"/rekey", map[string]any{"items": []string{"apple"}})
+	if status != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected status %d, got %d: %v", http.StatusMethodNotAllowed, status, result)
+	}
+}
@@ -0,0 +1,45 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func doItemRequest(t *testing.T, method, endpoint, item string) int {
+	t.Helper()
+	return doRequest(t, method, endpoint, nil, map[string]string{"item": item}).status
+}
+
+func TestRemoveCountingFilter(t *testing.T) {
+	useFilter(t, bloom.TypeCounting)
+	items := "/api/" + TestAPIVersion + "/items"
+
+	steps := []struct {
+		Name               string
+		Method             string
+		Endpoint           string
+		ExpectedStatusCode int
+	}{
+		{"Insert hello", http.MethodPost, getEndpoint(OpInsert), http.StatusCreated},
+		{"Remove hello", http.MethodDelete, items, http.StatusOK},
+		{"Lookup hello after removal", http.MethodPost, getEndpoint(OpLookup), http.StatusNotFound},
+		{"Remove hello again", http.MethodDelete, items, http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		if got := doItemRequest(t, step.Method, step.Endpoint, "hello"); got != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d", step.Name, step.ExpectedStatusCode, got)
+		}
+	}
+}
+
+func TestRemoveStandardFilter(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+
+	got := doItemRequest(t, http.MethodDelete, "/api/"+TestAPIVersion+"/items", "hello")
+	if got != http.StatusMethodNotAllowed {
+		t.Errorf("Expected %d, got %d", http.StatusMethodNotAllowed, got)
+	}
+}
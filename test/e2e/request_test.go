@@ -0,0 +1,138 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/api/v1/handlers"
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+	"github.com/vinit-chauhan/go-bloomservice/internal/registry"
+	"github.com/vinit-chauhan/go-bloomservice/internal/server"
+)
+
+// response is the status, headers and raw body of a response of the service
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// decode Decodes the JSON body of the response
+func (r response) decode() (map[string]any, error) {
+	var result map[string]any
+	if err := json.Unmarshal(r.body, &result); err != nil {
+		return nil, fmt.Errorf("decoding response %d %q: %w", r.status, r.body, err)
+	}
+	return result, nil
+}
+
+// sendRequest Sends a request to a new instance of the service. A []byte body
+// is sent as is, any other body as JSON. It returns errors rather than failing
+// the test, so it may be called from goroutines other than the test's.
+func sendRequest(method, endpoint string, headers map[string]string, body any) (response, error) {
+	var reader io.Reader
+	contentType := "application/json"
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(body)
+		contentType = "application/octet-stream"
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return response{}, fmt.Errorf("encoding request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, endpoint, reader)
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := server.StartServer().Test(req)
+	if err != nil {
+		return response{}, fmt.Errorf("%s %s: %w", method, endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response{}, fmt.Errorf("reading response of %s %s: %w", method, endpoint, err)
+	}
+	return response{status: resp.StatusCode, header: resp.Header, body: data}, nil
+}
+
+// doRequest Sends a request like sendRequest, failing the test on errors. It
+// must only be called from the test goroutine.
+func doRequest(t *testing.T, method, endpoint string, headers map[string]string, body any) response {
+	t.Helper()
+	resp, err := sendRequest(method, endpoint, headers, body)
+	if err != nil {
+		t.Fatalf("Failed request: %v", err)
+	}
+	return resp
+}
+
+// doHeaderRequest Sends a JSON request with the given headers, returning the
+// status, headers and decoded body of the response
+func doHeaderRequest(t *testing.T, method, endpoint string, headers map[string]string, body any) (int, http.Header, map[string]any) {
+	t.Helper()
+	resp := doRequest(t, method, endpoint, headers, body)
+	result, err := resp.decode()
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp.status, resp.header, result
+}
+
+func doJSONRequest(t *testing.T, method, endpoint string, body any) (int, map[string]any) {
+	t.Helper()
+	status, _, result := doHeaderRequest(t, method, endpoint, nil, body)
+	return status, result
+}
+
+// useFilter Replaces the registry of the service for the test with a fresh
+// one, whose default filter is of filterType
+func useFilter(t *testing.T, filterType bloom.FilterType) {
+	t.Helper()
+	previous := handlers.Filters
+	t.Cleanup(func() { handlers.Filters = previous })
+
+	params := bloom.CalculateOptimalParameters(10000, 0.01)
+	params.Type = filterType
+	handlers.Filters = registry.New()
+	handlers.Filters.SetDefaultParameters(params)
+	if _, err := defaultTenant(t).Create(registry.DefaultName, params); err != nil {
+		t.Fatalf("Failed to create filter: %v", err)
+	}
+}
+
+// defaultTenant Returns the tenant of requests that do not name one
+func defaultTenant(t *testing.T) *registry.Tenant {
+	t.Helper()
+	tenant, err := handlers.Filters.Tenant(registry.DefaultTenant)
+	if err != nil {
+		t.Fatalf("Failed to get the default tenant: %v", err)
+	}
+	return tenant
+}
+
+// defaultFilter Returns the filter the unnamed routes use
+func defaultFilter(t *testing.T) bloom.ProbabilisticFilter {
+	t.Helper()
+	entry, err := defaultTenant(t).Get(registry.DefaultName)
+	if err != nil {
+		t.Fatalf("Failed to get the default filter: %v", err)
+	}
+	filter, err := entry.Load()
+	if err != nil {
+		t.Fatalf("Failed to load the default filter: %v", err)
+	}
+	return filter
+}
@@ -0,0 +1,170 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vinit-chauhan/go-bloomservice/internal/api/v1/handlers"
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+	"github.com/vinit-chauhan/go-bloomservice/internal/registry"
+)
+
+// useTenants Lets the tenant header name the given tenants for the test
+func useTenants(t *testing.T, ids ...string) {
+	t.Helper()
+	handlers.SetTenants(ids)
+	t.Cleanup(func() { handlers.SetTenants(nil) })
+}
+
+func TestTenants(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	handlers.Filters.SetTenantLimits(registry.TenantLimits{MaxFilters: 1})
+	useTenants(t, "team-a", "team-b", "a/b")
+	filters := "/api/" + TestAPIVersion + "/filters"
+	teamA := map[string]string{handlers.TenantHeader: "team-a"}
+	teamB := map[string]string{handlers.TenantHeader: "team-b"}
+	create := map[string]any{"name": "users", "capacity": 1000, "false_positive_rate": 0.01}
+	item := map[string]any{"item": "apple"}
+
+	steps := []struct {
+		Name               string
+		Method             string
+		Endpoint           string
+		Headers            map[string]string
+		Body               any
+		ExpectedStatusCode int
+	}{
+		{"Create for tenant a", http.MethodPost, filters, teamA, create, http.StatusCreated},
+		{"Create the same name for tenant b", http.MethodPost, filters, teamB, create, http.StatusCreated},
+		{"Exceed the filter quota", http.MethodPost, filters, teamA, map[string]any{"name": "orders", "capacity": 1000, "false_positive_rate": 0.01}, http.StatusForbidden},
+		{"Insert for tenant a", http.MethodPost, filters + "/users/add", teamA, item, http.StatusCreated},
+		{"Lookup for tenant a", http.MethodPost, filters + "/users/exists", teamA, item, http.StatusOK},
+		{"Lookup for tenant b", http.MethodPost, filters + "/users/exists", teamB, item, http.StatusNotFound},
+		{"Lookup in the default tenant", http.MethodPost, filters + "/users/exists", nil, item, http.StatusNotFound},
+		{"Invalid tenant", http.MethodGet, filters, map[string]string{handlers.TenantHeader: "a/b"}, nil, http.StatusBadRequest},
+		{"Unknown tenant", http.MethodGet, filters, map[string]string{handlers.TenantHeader: "team-c"}, nil, http.StatusForbidden},
+	}
+	for _, step := range steps {
+		if status, _, result := doHeaderRequest(t, step.Method, step.Endpoint, step.Headers, step.Body); status != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d: %v", step.Name, step.ExpectedStatusCode, status, result)
+		}
+	}
+
+	status, _, result := doHeaderRequest(t, http.MethodGet, filters, teamA, nil)
+	if list := result["filters"].([]any); status != http.StatusOK || len(list) != 1 {
+		t.Fatalf("Expected tenant a to list its filter, got %d: %v", status, result)
+	}
+
+	status, _, result = doHeaderRequest(t, http.MethodGet, "/api/"+TestAPIVersion+"/tenant", teamA, nil)
+	if status != http.StatusOK {
+		t.Fatalf("Expected %d, got %d: %v", http.StatusOK, status, result)
+	}
+	if result["tenant"] != "team-a" || result["filters"].(float64) != 1 || result["ops"].(float64) != 2 {
+		t.Fatalf("Expected one filter and two operations for tenant a, got %v", result)
+	}
+
+	// the memory report lists only the filters of the tenant
+	status, _, result = doHeaderRequest(t, http.MethodGet, "/api/"+TestAPIVersion+"/memory", teamA, nil)
+	if status != http.StatusOK {
+		t.Fatalf("Expected %d, got %d: %v", http.StatusOK, status, result)
+	}
+	list := result["filters"].([]any)
+	if len(list) != 1 || list[0].(map[string]any)["tenant"] != "team-a" {
+		t.Fatalf("Expected the memory of tenant a to list its filter only, got %v", list)
+	}
+}
+
+func TestTenantDefaultFilter(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	handlers.Filters.SetTenantLimits(registry.TenantLimits{MaxFilters: 1})
+	useTenants(t, "team-a", "team-b")
+	teamA := map[string]string{handlers.TenantHeader: "team-a"}
+	teamB := map[string]string{handlers.TenantHeader: "team-b"}
+	item := map[string]any{"item": "apple"}
+	create := map[string]any{"name": "users", "capacity": 1000, "false_positive_rate": 0.01}
+
+	// the unnamed routes create the default filter of a tenant on first use,
+	// counted against its quota
+	steps := []struct {
+		Name               string
+		Method             string
+		Endpoint           string
+		Headers            map[string]string
+		Body               any
+		ExpectedStatusCode int
+	}{
+		{"Insert for tenant a", http.MethodPost, getEndpoint(OpInsert), teamA, item, http.StatusCreated},
+		{"Lookup for tenant a", http.MethodPost, getEndpoint(OpLookup), teamA, item, http.StatusOK},
+		{"Lookup in the default tenant", http.MethodPost, getEndpoint(OpLookup), nil, item, http.StatusNotFound},
+		{"Stats for tenant a", http.MethodGet, "/api/" + TestAPIVersion + "/stats", teamA, nil, http.StatusOK},
+		{"Exceed the filter quota by name", http.MethodPost, "/api/" + TestAPIVersion + "/filters", teamA, create, http.StatusForbidden},
+		{"Create a filter for tenant b", http.MethodPost, "/api/" + TestAPIVersion + "/filters", teamB, create, http.StatusCreated},
+		{"Exceed the filter quota by default", http.MethodPost, getEndpoint(OpInsert), teamB, item, http.StatusForbidden},
+	}
+	for _, step := range steps {
+		if status, _, result := doHeaderRequest(t, step.Method, step.Endpoint, step.Headers, step.Body); status != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d: %v", step.Name, step.ExpectedStatusCode, status, result)
+		}
+	}
+}
+
+func TestTenantRateLimit(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	handlers.Filters.SetTenantLimits(registry.TenantLimits{OpsPerSecond: 5})
+	useTenants(t, "team-a", "team-b")
+	params := bloom.CalculateOptimalParameters(1000, 0.01)
+	for _, id := range []string{"team-a", "team-b"} {
+		tenant, _ := handlers.Filters.Tenant(id)
+		if _, err := tenant.Create("users", params); err != nil {
+			t.Fatalf("Failed to create filter: %v", err)
+		}
+	}
+	users := "/api/" + TestAPIVersion + "/filters/users"
+	batch := map[string]any{"items": []string{"a", "b", "c", "d", "e"}}
+	teamA := map[string]string{handlers.TenantHeader: "team-a"}
+
+	if status, _, result := doHeaderRequest(t, http.MethodPost, users+"/add/batch", teamA, batch); status != http.StatusCreated {
+		t.Fatalf("Expected %d, got %d: %v", http.StatusCreated, status, result)
+	}
+	status, header, result := doHeaderRequest(t, http.MethodPost, users+"/exists", teamA, map[string]any{"item": "a"})
+	if status != http.StatusTooManyRequests || header.Get("Retry-After") == "" {
+		t.Fatalf("Expected %d with Retry-After, got %d: %v", http.StatusTooManyRequests, status, result)
+	}
+	// merges are charged too, whatever the size of the filter
+	if status, _, result := doHeaderRequest(t, http.MethodPost, users+"/merge", teamA, map[string]any{}); status != http.StatusTooManyRequests {
+		t.Fatalf("Expected the merge to be throttled, got %d: %v", status, result)
+	}
+
+	// the bulk load of one tenant does not starve the lookups of another
+	teamB := map[string]string{handlers.TenantHeader: "team-b"}
+	if status, _, result := doHeaderRequest(t, http.MethodPost, users+"/exists", teamB, map[string]any{"item": "a"}); status != http.StatusNotFound {
+		t.Fatalf("Expected %d, got %d: %v", http.StatusNotFound, status, result)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	handlers.SetAPIKeys(map[string]string{"secret-a": "team-a", "secret-d": registry.DefaultTenant})
+	t.Cleanup(func() { handlers.SetAPIKeys(nil) })
+	tenant := "/api/" + TestAPIVersion + "/tenant"
+
+	steps := []struct {
+		Name               string
+		Headers            map[string]string
+		ExpectedStatusCode int
+		ExpectedTenant     string
+	}{
+		{"Missing key", nil, http.StatusUnauthorized, ""},
+		{"Unknown key", map[string]string{"X-API-Key": "guess"}, http.StatusUnauthorized, ""},
+		{"Key of tenant a", map[string]string{"X-API-Key": "secret-a"}, http.StatusOK, "team-a"},
+		{"Header ignored for a key", map[string]string{"X-API-Key": "secret-d", handlers.TenantHeader: "team-a"}, http.StatusOK, registry.DefaultTenant},
+	}
+	for _, step := range steps {
+		status, _, result := doHeaderRequest(t, http.MethodGet, tenant, step.Headers, nil)
+		if status != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d: %v", step.Name, step.ExpectedStatusCode, status, result)
+		} else if step.ExpectedTenant != "" && result["tenant"] != step.ExpectedTenant {
+			t.Errorf("Step %q: expected tenant %s, got %v", step.Name, step.ExpectedTenant, result["tenant"])
+		}
+	}
+}
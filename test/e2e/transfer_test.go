@@ -0,0 +1,200 @@
+package e2e
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vinit-chauhan/go-bloomservice/internal/api/v1/handlers"
+	"github.com/vinit-chauhan/go-bloomservice/internal/bloom"
+)
+
+func doTransferRequest(t *testing.T, method, endpoint, encoding string, body []byte) (int, []byte) {
+	t.Helper()
+	var headers map[string]string
+	if encoding != "" {
+		headers = map[string]string{"Content-Encoding": encoding}
+	}
+	resp := doRequest(t, method, endpoint, headers, body)
+	return resp.status, resp.body
+}
+
+func TestExport(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	defaultFilter(t).Add("exported")
+	export := "/api/" + TestAPIVersion + "/export"
+
+	decoders := map[string]func([]byte) ([]byte, error){
+		"": func(data []byte) ([]byte, error) { return data, nil },
+		"gzip": func(data []byte) ([]byte, error) {
+			r, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(r)
+		},
+		"zstd": func(data []byte) ([]byte, error) {
+			r, err := zstd.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+			return io.ReadAll(r)
+		},
+	}
+	for compression, decode := range decoders {
+		status, body := doTransferRequest(t, http.MethodGet, export+"?compression="+compression, "", nil)
+		if status != http.StatusOK {
+			t.Fatalf("Compression %q: expected %d, got %d: %s", compression, http.StatusOK, status, body)
+		}
+
+		data, err := decode(body)
+		if err != nil {
+			t.Fatalf("Compression %q: failed to decompress export: %v", compression, err)
+		}
+		filter, err := bloom.ReadFilter(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Compression %q: failed to read export: %v", compression, err)
+		}
+		if !filter.Exists("exported") {
+			t.Fatalf("Compression %q: expected the exported filter to hold the live items", compression)
+		}
+	}
+
+	if status, _ := doTransferRequest(t, http.MethodGet, export+"?compression=lz4", "", nil); status != http.StatusBadRequest {
+		t.Errorf("Unknown compression: expected %d, got %d", http.StatusBadRequest, status)
+	}
+
+	useFilter(t, bloom.TypeCuckoo)
+	if status, _ := doTransferRequest(t, http.MethodGet, export, "", nil); status != http.StatusMethodNotAllowed {
+		t.Errorf("Cuckoo filter: expected %d, got %d", http.StatusMethodNotAllowed, status)
+	}
+}
+
+func TestImport(t *testing.T) {
+	useFilter(t, bloom.TypeCuckoo)
+	importEndpoint := "/api/" + TestAPIVersion + "/import"
+
+	uploaded := bloom.New(bloom.CalculateOptimalParameters(1000, 0.01))
+	uploaded.Add("imported")
+	data, err := uploaded.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal filter: %v", err)
+	}
+
+	var zstdData bytes.Buffer
+	zw, _ := zstd.NewWriter(&zstdData)
+	zw.Write(data)
+	zw.Close()
+
+	keyed, _ := bloom.New(bloom.CalculateOptimalParameters(1000, 0.01), bloom.WithKey([]byte("secret"))).MarshalBinary()
+
+	steps := []struct {
+		Name               string
+		Encoding           string
+		Body               []byte
+		ExpectedStatusCode int
+	}{
+		{"Garbage", "", []byte("not a filter"), http.StatusBadRequest},
+		{"Truncated", "", data[:len(data)-1], http.StatusBadRequest},
+		{"Trailing bytes", "", append(bytes.Clone(data), 0), http.StatusBadRequest},
+		{"Bad zstd", "zstd", data, http.StatusBadRequest},
+		{"Unknown encoding", "br", data, http.StatusBadRequest},
+		{"Other key", "", keyed, http.StatusConflict},
+	}
+	for _, step := range steps {
+		live := defaultFilter(t)
+		if status, body := doTransferRequest(t, http.MethodPut, importEndpoint, step.Encoding, step.Body); status != step.ExpectedStatusCode {
+			t.Errorf("Step %q: expected %d, got %d: %s", step.Name, step.ExpectedStatusCode, status, body)
+		}
+		if defaultFilter(t) != live {
+			t.Fatalf("Step %q: expected a refused import to keep the live filter", step.Name)
+		}
+	}
+
+	if status, body := doTransferRequest(t, http.MethodPut, importEndpoint, "zstd", zstdData.Bytes()); status != http.StatusCreated {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusCreated, status, body)
+	}
+	if got := defaultFilter(t).GetParameters().Type; got != bloom.TypeStandard {
+		t.Fatalf("Expected the imported standard filter to be live, got %s", got)
+	}
+	if got := doItemRequest(t, http.MethodPost, getEndpoint(OpLookup), "imported"); got != http.StatusOK {
+		t.Fatalf("Expected imported item to exist, got %d", got)
+	}
+}
+
+func TestImportBodyLimit(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	importEndpoint := "/api/" + TestAPIVersion + "/import"
+
+	uploaded := bloom.New(bloom.CalculateOptimalParameters(100_000, 0.01))
+	for i := range 50_000 {
+		uploaded.Add(strconv.Itoa(i))
+	}
+	data, _ := uploaded.MarshalBinary()
+
+	var gzipped, zstdData bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(data)
+	gw.Close()
+	zw, _ := zstd.NewWriter(&zstdData)
+	zw.Write(data)
+	zw.Close()
+
+	limit := handlers.MaxBodySize
+	handlers.MaxBodySize = len(data) - 1
+	t.Cleanup(func() { handlers.MaxBodySize = limit })
+
+	// the compressed bodies fit, but decompress beyond the limit
+	for encoding, body := range map[string][]byte{"gzip": gzipped.Bytes(), "zstd": zstdData.Bytes()} {
+		if status, resp := doTransferRequest(t, http.MethodPut, importEndpoint, encoding, body); status != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: expected %d, got %d: %s", encoding, http.StatusRequestEntityTooLarge, status, resp)
+		}
+	}
+
+	handlers.MaxBodySize = len(data)
+	if status, resp := doTransferRequest(t, http.MethodPut, importEndpoint, "gzip", gzipped.Bytes()); status != http.StatusCreated {
+		t.Fatalf("Expected a body of exactly the limit to be imported, got %d: %s", status, resp)
+	}
+}
+
+func TestImportDeclaredSize(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+
+	// a tiny upload declaring 2^40 bits must not be decoded
+	data, _ := bloom.New(bloom.CalculateOptimalParameters(1000, 0.01)).MarshalBinary()
+	binary.LittleEndian.PutUint64(data[8:], 1<<40)
+	if status, resp := doTransferRequest(t, http.MethodPut, "/api/"+TestAPIVersion+"/import", "", data); status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusRequestEntityTooLarge, status, resp)
+	}
+}
+
+func TestMergeSerialized(t *testing.T) {
+	useFilter(t, bloom.TypeStandard)
+	remote := bloom.New(defaultFilter(t).GetParameters())
+	remote.Add("remote-item")
+	data, _ := remote.MarshalBinary()
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(data)
+	gw.Close()
+
+	merge := "/api/" + TestAPIVersion + "/merge"
+	if status, body := doTransferRequest(t, http.MethodPost, merge+"?operation=union", "gzip", gzipped.Bytes()); status != http.StatusOK {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusOK, status, body)
+	}
+	if got := doItemRequest(t, http.MethodPost, getEndpoint(OpLookup), "remote-item"); got != http.StatusOK {
+		t.Fatalf("Expected remote-item to exist after the merge, got %d", got)
+	}
+
+	other, _ := bloom.New(bloom.CalculateOptimalParameters(10, 0.01)).MarshalBinary()
+	if status, _ := doTransferRequest(t, http.MethodPost, merge, "", other); status != http.StatusConflict {
+		t.Errorf("Incompatible filter: expected %d, got %d", http.StatusConflict, status)
+	}
+}